@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes, decoded from strings such as "512",
+// "10MiB" or "1.5GB". Units are case-insensitive: KB, MB, GB, TB and PB are
+// powers of 1000, KiB, MiB, GiB, TiB and PiB as well as the single letters
+// K, M, G, T and P are powers of 1024.
+type ByteSize uint64
+
+// Byte sizes.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+	PB          = 1000 * TB
+
+	KiB ByteSize = 1024 * Byte
+	MiB          = 1024 * KiB
+	GiB          = 1024 * MiB
+	TiB          = 1024 * GiB
+	PiB          = 1024 * TiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"kb": KB, "mb": MB, "gb": GB, "tb": TB, "pb": PB,
+	"kib": KiB, "mib": MiB, "gib": GiB, "tib": TiB, "pib": PiB,
+	"k": KiB, "m": MiB, "g": GiB, "t": TiB, "p": PiB,
+}
+
+// ParseByteSize parses a byte size such as "10MiB", see ByteSize.
+func ParseByteSize(s string) (size ByteSize, err error) {
+	trimmed := strings.TrimSpace(s)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(trimmed)
+	}
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(trimmed[i:]))]
+	if !ok || i == 0 {
+		err = fmt.Errorf("invalid byte size %q", s)
+		return
+	}
+
+	if n, parseErr := strconv.ParseUint(trimmed[:i], 10, 64); parseErr == nil {
+		if n > math.MaxUint64/uint64(unit) {
+			err = fmt.Errorf("byte size %q overflows", s)
+			return
+		}
+		size = ByteSize(n) * unit
+		return
+	}
+	f, err := strconv.ParseFloat(trimmed[:i], 64)
+	if err != nil {
+		err = fmt.Errorf("invalid byte size %q", s)
+		return
+	}
+	f *= float64(unit)
+	if f >= math.MaxUint64 {
+		err = fmt.Errorf("byte size %q overflows", s)
+		return
+	}
+	size = ByteSize(math.Round(f))
+	return
+}
+
+// String formats b in the largest binary unit that divides it, i.e. "10MiB".
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		size ByteSize
+		name string
+	}{{PiB, "PiB"}, {TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"}} {
+		if b >= u.size && b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) (err error) {
+	*b, err = ParseByteSize(string(text))
+	return
+}
@@ -2,10 +2,10 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -13,10 +13,12 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // UserBase and SystemBase are the prefixes for the user and system config
-// paths, respectively.
+// paths, respectively. XDGConfigHome overrides UserBase, and the directories
+// of XDGConfigDirs are searched before SystemBase.
 const (
 	UserBase   string = "~/.config/"
 	SystemBase string = "/etc/"
@@ -25,6 +27,14 @@ const (
 // Unmarshaller defines the function signature for unmarshal functions
 type Unmarshaller func(data []byte, v interface{}) error
 
+// Marshaller defines the function signature for marshal functions
+type Marshaller func(v interface{}) ([]byte, error)
+
+// Editor defines the function signature for functions setting the value of
+// the key at a dotted path, such as "database.port", in config data while
+// preserving the comments and key order of the rest of the document
+type Editor func(data []byte, key string, value interface{}) ([]byte, error)
+
 // FileFormat is the type of config file to unmarshal
 type FileFormat struct {
 	// file extension, i.e. "yaml" for a file named "config.yaml"
@@ -32,6 +42,56 @@ type FileFormat struct {
 
 	// Unmarshaller used to unmarshal config data
 	Unmarshaller Unmarshaller
+
+	// Marshaller used to marshal config data, optional unless the config is
+	// saved
+	Marshaller Marshaller
+
+	// Editor used to change single keys of config data in place, optional
+	Editor Editor
+
+	// Locator used to find the line and column of keys for LoadProvenance,
+	// optional
+	Locator Locator
+
+	// struct tag honored by Unmarshaller, i.e. "yaml". Defaults to Extension.
+	Tag string
+
+	// media types of the format, i.e. "application/yaml", the first being
+	// the canonical one
+	MIMETypes []string
+
+	// Sniff reports whether data looks like it is in this format. Optional,
+	// used to detect the format of configs without a known media type or
+	// extension.
+	Sniff func(data []byte) bool
+}
+
+func (f *FileFormat) tag() string {
+	if f.Tag == "" {
+		return f.Extension
+	}
+	return f.Tag
+}
+
+// formats returns the formats searched for config files.
+func (c Config) formats() []*FileFormat {
+	if len(c.Formats) > 0 {
+		return c.Formats
+	}
+	return []*FileFormat{c.FileFormat}
+}
+
+// formatOf returns the searched format whose extension matches path, or
+// fallback if there is none.
+func (c Config) formatOf(path string, fallback *FileFormat) *FileFormat {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, f := range c.formats() {
+		if f != nil && f.Extension != "" && strings.EqualFold(f.Extension, ext) {
+			return f
+		}
+	}
+	return fallback
 }
 
 // Config implements Loader
@@ -45,13 +105,116 @@ type Config struct {
 	// describes the type of config file to unmarshal
 	FileFormat *FileFormat
 
+	// Formats are probed in order at every level of the hierarchy, and the
+	// config file found decides the format it is decoded with. Load fails with
+	// ErrAmbiguousFormat if a level has config files in more than one format.
+	// FileFormat, if set, decodes DefaultData and serves as the format when
+	// no config file is found, otherwise the first of Formats does.
+	Formats []*FileFormat
+
+	// DetectFormat chooses the format of every config among Formats by the
+	// media type reported by its Source, such as the Content-Type of an http
+	// response, then by the extension of its path, then by sniffing its
+	// content. The format a config was searched with is used when none of
+	// them match.
+	DetectFormat bool
+
+	// ConfigTags decodes every format through a format-neutral tree, naming
+	// the fields of the destination by their `config:"name"` struct tags
+	// instead of the tags of the format, so one struct works with all of
+	// them. Keys match fields ignoring case, underscores and dashes, and
+	// untagged fields by their name, so MaxConns matches max_conns,
+	// max-conns and maxConns. Save encodes with the same names.
+	ConfigTags bool
+
+	// FileName is the base name of config files, without extension. Defaults
+	// to "config".
+	FileName string
+
+	// SearchPaths are templates of the config files searched by URI(), in
+	// order of decreasing precedence. Defaults to DefaultSearchPaths. The
+	// following placeholders are replaced:
+	//
+	//	{org}           Organization
+	//	{service}       Service
+	//	{name}          FileName
+	//	{ext}           extension of the FileFormat
+	//	{file}          {name}.{ext}, or {name} without an extension
+	//	{home}          home directory of the current user
+	//	{hostname}      host name reported by the kernel
+	//	{user_config}   $XDG_CONFIG_HOME or ~/.config
+	//	{system_config} each entry of $XDG_CONFIG_DIRS, then /etc
+	//
+	// Templates may start with "~/" or "$HOME", relative paths are resolved
+	// against the working directory.
+	SearchPaths []string
+
+	// Layered merges the system config, the user config and the config
+	// referenced by EnvVar(), in that order of increasing precedence, instead
+	// of loading only the one returned by URI(). With SearchPaths, every
+	// existing candidate is merged with the first one taking precedence.
+	Layered bool
+
+	// EnvOverrides applies per-field environment variables named after
+	// EnvPrefix() onto the destination after the config files are decoded.
+	// Load succeeds without any config file when EnvOverrides is set.
+	EnvOverrides bool
+
+	// Defaults is a value of the destination's type copied into the
+	// destination before anything else is decoded.
+	Defaults interface{}
+
+	// DefaultData is a config file, typically embedded with go:embed, decoded
+	// before the config files found on disk. Load succeeds without any config
+	// file when DefaultData is set.
+	DefaultData []byte
+
+	// Validate checks the destination with Validate once it is loaded. It is
+	// off by default so `validate` tags meant for another validator are left
+	// alone.
+	Validate bool
+
+	// PollInterval is the interval at which Watch polls local files when file
+	// system notifications are unavailable, defaulting to DefaultPollInterval,
+	// and remote configs, defaulting to DefaultPollerInterval.
+	PollInterval time.Duration
+
+	// HTTPClient is used to fetch http and https config URIs. Defaults to a
+	// client with a DefaultHTTPTimeout timeout.
+	HTTPClient *http.Client
+
+	// HTTPHeader is added to every http request, i.e. for authorization.
+	HTTPHeader http.Header
+
+	// MaxBodySize limits the size of configs fetched over http. Defaults to
+	// DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// MaxRedirects limits the number of http redirects followed. Defaults to
+	// DefaultMaxRedirects, a negative value disables redirects.
+	MaxRedirects int
+
+	// Logger, if set, receives a debug level trace of how the config is
+	// resolved: EnvVar() and its value, every candidate with the result of
+	// stat, and which config was chosen and which were shadowed.
+	Logger *slog.Logger
+
 	// used for mocking expanduser
 	pathExpander func(p string) string
+
+	// used for mocking SystemBase
+	systemBase string
+
+	// format of the config with the highest precedence, set while loading
+	format *FileFormat
+
+	// records the origin of every value when set, see LoadProvenance
+	provenance Provenance
 }
 
 var (
-	// ErrNilUnmarshaller is returned when an undefined unmarshaller is passed to
-	// load()
+	// ErrNilUnmarshaller is returned when the FileFormat a config is decoded
+	// with has no Unmarshaller
 	ErrNilUnmarshaller = errors.New("config: nil unmarshaller")
 
 	// ErrNilFileFormat is returned when the config struct contains a nil FileFormat
@@ -61,166 +224,261 @@ var (
 	// or /etc/:organization/:systems/config.{extension} are missing
 	ErrConfigFileNotFound = errors.New("config: missing config files")
 
-	// ErrNotAPointer is returned when a non-pointer is passed into load
+	// ErrNotAPointer is returned when a non-pointer is passed into Load
 	ErrNotAPointer = errors.New("config: not a pointer")
+
+	// ErrAmbiguousFormat is returned when config files in more than one of
+	// Formats exist at the same level of the hierarchy
+	ErrAmbiguousFormat = errors.New("config: config files in multiple formats")
 )
 
-// readHTTP reads *http.Response.ContentLength bytes of *http.Response.Body
-// and returns as []byte
-func readHTTP(uri string) (data []byte, err error) {
-	resp, err := http.Get(uri)
+// fetch reads the contents of the resource at src with the Source registered
+// for its scheme, along with their media type if the Source reports one.
+func (c Config) fetch(ctx context.Context, src string) (data []byte, contentType string, err error) {
+	uri, err := url.Parse(src)
 	if err != nil {
 		return
 	}
 
-	defer func() {
-		closeErr := resp.Body.Close()
-		if err == nil {
-			err = closeErr
-		}
+	source, ok := lookupSource(uri.Scheme)
+	if !ok {
+		err = fmt.Errorf("%w: %q", ErrUnsupportedScheme, uri.Scheme)
 		return
-	}()
+	}
+	if cs, ok := source.(configurable); ok {
+		source = cs.configure(c)
+	}
 
-	data = make([]byte, resp.ContentLength)
-	bytesRead, err := io.ReadFull(resp.Body, data)
-	if err != nil {
+	if ts, ok := source.(TypedSource); ok {
+		data, contentType, err = ts.FetchTyped(ctx, uri)
 		return
 	}
-	if int64(bytesRead) != resp.ContentLength {
-		err = fmt.Errorf("config: incomplete http response read: %d bytes read of content-length %d", bytesRead, resp.ContentLength)
+	data, err = source.Fetch(ctx, uri)
+	return
+}
+
+func expandUser(usr *user.User, path string) (exPath string) {
+	dir := fmt.Sprintf("%s/", usr.HomeDir)
+
+	exPath = path
+	if len(path) >= 2 && path[:2] == "~/" {
+		exPath = strings.Replace(exPath, "~/", dir, 1)
+	} else if len(path) >= 5 && path[:5] == "$HOME" {
+		exPath = strings.Replace(exPath, "$HOME", dir, 1)
+	}
+
+	exPath, _ = filepath.Abs(filepath.Clean(exPath))
+	return
+}
+
+// ExpandUser acts kind of like os.path.expanduser in Python, except only
+// supports expanding "~/" or "$HOME"
+func ExpandUser(path string) (exPath string) {
+	usr, err := user.Current()
+	if usr == nil || err != nil {
 		return
 	}
+	exPath = expandUser(usr, path)
 	return
 }
 
-func uriParser(src string) (data []byte, err error) {
-	uri, err := url.Parse(src)
+// Path returns path to config, chosen by hierarchy as described by URI. For
+// configs outside the local file system the full URI is returned. Path
+// returns an empty string when URI fails.
+func (c Config) Path() (path string) {
+	uri, err := c.URI()
 	if err != nil {
 		return
 	}
 
-	switch {
-	case uri.Scheme == "file" || uri.Scheme == "":
-		data, err = ioutil.ReadFile(uri.Path)
-		if err != nil {
-			return
-		}
-		return
-	case uri.Scheme == "http" || uri.Scheme == "https":
-		data, err = readHTTP(uri.String())
-		if err != nil {
-			return
-		}
+	if uri.Scheme == "file" || uri.Scheme == "" {
+		path = uri.Path
 		return
 	}
+	path = uri.String()
 	return
 }
 
-// load reads the contents of the file at the provided src uri and uses the
-// provided unmarshaller to
-func load(unmarshaller Unmarshaller, src string, dst interface{}) (err error) {
-	if unmarshaller == nil {
-		err = ErrNilUnmarshaller
+// URI returns the URI of the config, chosen by hierarchy:
+//
+// 1. {ORGANIZATION}_{SERVICE}_CONFIG_URI environment variable, which may be a
+// local path or a URI of any registered scheme. It is used without checking
+// for existence, so a config that can't be fetched fails to load instead of
+// silently falling back to the files below.
+//
+// 2. User config (~/.config/podhub/canary/config.{extension}), if it exists.
+// $XDG_CONFIG_HOME replaces ~/.config when set.
+//
+// 3. System config (/etc/podhub/canary/config.{extension}), if it exists.
+// When $XDG_CONFIG_DIRS is set, each of its directories is searched in turn
+// before /etc.
+//
+// SearchPaths replaces steps 2 and 3 with its own list of candidates. With
+// Formats, each candidate is looked for with the extension of every format.
+// A *NotFoundError listing every candidate and why it was rejected is returned
+// when none of them is available; it matches ErrConfigFileNotFound.
+func (c Config) URI() (uri *url.URL, err error) {
+	found, err := c.resolve()
+	uri = found.uri
+	return
+}
+
+// resolve returns the config chosen by URI() along with its format.
+func (c Config) resolve() (found candidate, err error) {
+	c.traceEnv()
+	uri, err := c.envURI()
+	if err != nil {
 		return
 	}
-
-	if reflect.ValueOf(dst).Kind() != reflect.Ptr {
-		err = ErrNotAPointer
+	if uri != nil {
+		found = candidate{uri: uri, format: c.formatOf(uri.Path, c.defaultFormat())}
+		c.trace("config: chosen", "uri", uri.Redacted(), "reason", "set in "+c.EnvVar())
+		c.traceShadowed(uri.Redacted())
 		return
 	}
 
-	data, err := uriParser(src)
+	existing, rejected, err := c.lookup()
 	if err != nil {
 		return
 	}
-
-	err = unmarshaller(data, dst)
-	if err != nil {
+	if len(existing) == 0 {
+		err = &NotFoundError{Candidates: rejected}
+		c.trace("config: none found")
 		return
 	}
+	found = existing[0]
+	c.trace("config: chosen", "uri", found.uri.Path, "reason", "first existing candidate")
+	for _, shadowed := range existing[1:] {
+		c.trace("config: shadowed", "uri", shadowed.uri.Path, "by", found.uri.Path)
+	}
 	return
 }
 
-func expandUser(usr *user.User, path string) (exPath string) {
-	dir := fmt.Sprintf("%s/", usr.HomeDir)
+// defaultFormat returns the format used when no config file decides it.
+func (c Config) defaultFormat() *FileFormat {
+	if c.FileFormat != nil || len(c.Formats) == 0 {
+		return c.FileFormat
+	}
+	return c.Formats[0]
+}
 
-	exPath = path
-	if len(path) >= 2 && path[:2] == "~/" {
-		exPath = strings.Replace(exPath, "~/", dir, 1)
-	} else if len(path) >= 5 && path[:5] == "$HOME" {
-		exPath = strings.Replace(exPath, "$HOME", dir, 1)
+// ConfigTag is the struct tag naming fields with ConfigTags.
+const ConfigTag = "config"
+
+// unmarshal decodes data in format into dst, through a format-neutral tree
+// with ConfigTags. Otherwise the Unmarshaller of the format decodes dst, and
+// values of types with a decode hook or encoding.TextUnmarshaler, which not
+// every format knows about, are decoded again from their strings. Should the
+// Unmarshaller fail, or panic, on such a destination, dst is decoded from
+// the tree instead.
+func (c Config) unmarshal(format *FileFormat, data []byte, dst interface{}) (err error) {
+	if c.ConfigTags {
+		var tree map[string]interface{}
+		tree, err = unmarshalTree(format.Unmarshaller, data)
+		if err != nil {
+			return
+		}
+		d := &decoder{tags: []string{ConfigTag}, fuzzy: true}
+		err = d.decode(nil, tree, reflect.ValueOf(dst))
+		return
 	}
 
-	exPath, _ = filepath.Abs(filepath.Clean(exPath))
-	return
-}
+	if !decodesTextWithin(reflect.TypeOf(dst)) {
+		err = format.Unmarshaller(data, dst)
+		return
+	}
 
-// ExpandUser acts kind of like os.path.expanduser in Python, except only
-// supports expanding "~/" or "$HOME"
-func ExpandUser(path string) (exPath string) {
-	usr, err := user.Current()
-	if usr == nil || err != nil {
+	nativeErr := unmarshalRecovered(format.Unmarshaller, data, dst)
+	tree, err := unmarshalTree(format.Unmarshaller, data)
+	if err != nil {
 		return
 	}
-	exPath = expandUser(usr, path)
+	d := &decoder{tags: []string{format.tag()}}
+	if nativeErr != nil {
+		err = d.decode(nil, tree, reflect.ValueOf(dst))
+		return
+	}
+	err = d.redecodeText(nil, tree, reflect.ValueOf(dst))
 	return
 }
 
-// Path returns path to config, chosen by hierarchy and checked for
-// existence:
-//
-// 1. {ORGANIZATION}_{SERVICE}_CONFIG_URI environment variable
-//
-// 2. User config (~/.config/podhub/canary/config.{extension})
-//
-// 3. System config (/etc/podhub/canary/config.{extension})
-func (c Config) Path() (path string) {
-	envVarPath := c.EnvVar()
-	if envVarPath != "" {
-		if _, err := os.Stat(envVarPath); err == nil {
-			path = envVarPath
-			return
+// unmarshalRecovered calls unmarshaller, turning a panic into an error, as
+// some panic on types they don't support, like go-ini does on net.IP.
+func unmarshalRecovered(unmarshaller Unmarshaller, data []byte, dst interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("config: unmarshaller panicked: %v", r)
 		}
+	}()
+	err = unmarshaller(data, dst)
+	return
+}
+
+// tag returns the struct tag honored when decoding into the destination.
+func (c Config) tag() string {
+	if c.ConfigTags {
+		return ConfigTag
 	}
+	if c.format != nil {
+		return c.format.tag()
+	}
+	return c.defaultFormat().tag()
+}
 
-	userPath := c.userURI().Path
-	if _, err := os.Stat(userPath); err == nil {
-		path = userPath
+// envURI parses the value of EnvVar(), returning nil if it is unset.
+func (c Config) envURI() (uri *url.URL, err error) {
+	value := os.Getenv(c.EnvVar())
+	if value == "" {
 		return
 	}
 
-	systemPath := c.systemURI().Path
-	if _, err := os.Stat(systemPath); err == nil {
-		path = systemPath
+	uri, err = url.Parse(value)
+	if err != nil {
+		err = fmt.Errorf("config: invalid %s: %w", c.EnvVar(), err)
 		return
 	}
+	if _, ok := lookupSource(uri.Scheme); !ok {
+		uri = nil
+		err = fmt.Errorf("%w in %s: %q", ErrUnsupportedScheme, c.EnvVar(), value)
+	}
 	return
 }
 
 const fileNamePrefix = "config"
 
+func (c Config) baseName() string {
+	if c.FileName == "" {
+		return fileNamePrefix
+	}
+	return c.FileName
+}
+
 func (c Config) fileName() string {
 	if c.FileFormat == nil || c.FileFormat.Extension == "" {
-		return fileNamePrefix
+		return c.baseName()
 	}
-	return fmt.Sprintf("%s.%s", fileNamePrefix, c.FileFormat.Extension)
+	return fmt.Sprintf("%s.%s", c.baseName(), c.FileFormat.Extension)
 }
 
+// systemURI returns the system config in the system directory with the
+// highest precedence.
 func (c Config) systemURI() (uri *url.URL) {
-	path := filepath.Join(SystemBase, c.Organization, c.Service, c.fileName())
-	uri = &url.URL{Path: path, Scheme: "file"}
+	uri = c.systemURIs()[0]
 	return
 }
 
-func (c Config) userURI() (uri *url.URL) {
-	var userBase string
-	if c.pathExpander == nil {
-		userBase = ExpandUser(UserBase)
-	} else {
-		userBase = c.pathExpander(UserBase)
+// systemURIs returns the system configs in every system directory, in order
+// of decreasing precedence.
+func (c Config) systemURIs() (uris []*url.URL) {
+	for _, base := range c.systemBases() {
+		path := filepath.Join(base, c.Organization, c.Service, c.fileName())
+		uris = append(uris, &url.URL{Path: path, Scheme: "file"})
 	}
+	return
+}
 
-	path := filepath.Join(userBase, c.Organization, c.Service, c.fileName())
+func (c Config) userURI() (uri *url.URL) {
+	path := filepath.Join(c.userBase(), c.Organization, c.Service, c.fileName())
 	uri = &url.URL{Path: path, Scheme: "file"}
 	return
 }
@@ -229,31 +487,210 @@ func (c Config) userURI() (uri *url.URL) {
 // of the config.
 // Example: PODHUB_UUIDD_CONFIG_URI
 func (c Config) EnvVar() (envvar string) {
+	envvar = c.EnvPrefix() + "CONFIG_URI"
+	return
+}
+
+// EnvPrefix returns the prefix shared by all environment variables read for
+// this config.
+// Example: PODHUB_UUIDD_
+func (c Config) EnvPrefix() (prefix string) {
 	var s []string
 	if c.Organization == "" {
-		s = []string{c.Service, "CONFIG", "URI"}
+		s = []string{c.Service, ""}
 	} else {
-		s = []string{c.Organization, c.Service, "CONFIG", "URI"}
+		s = []string{c.Organization, c.Service, ""}
 	}
-	envvar = strings.ToUpper(strings.Join(s, "_"))
+	prefix = strings.ToUpper(strings.Join(s, "_"))
 	return
 }
 
 // Load is a convenience function registered to config.Namespace to
-// implement Config.Load().
+// implement Config.Load(). It is LoadContext with context.Background().
 func (c Config) Load(dst interface{}) (err error) {
-	if c.FileFormat == nil {
+	err = c.LoadContext(context.Background(), dst)
+	return
+}
+
+// LoadContext loads the config into dst like Load. Fetching sources stops
+// when ctx is cancelled or its deadline passes, with an error that matches
+// ctx.Err() under errors.Is.
+func (c Config) LoadContext(ctx context.Context, dst interface{}) (err error) {
+	if c.defaultFormat() == nil {
 		err = ErrNilFileFormat
 		return
 	}
 
-	cfgPath := c.Path()
+	err = c.applyDefaults(dst)
+	if err != nil {
+		return
+	}
+
+	src := c.provenance
+	if src == nil {
+		src = make(Provenance)
+	}
+	if c.Layered {
+		c.format, err = c.loadLayered(ctx, dst, src)
+	} else {
+		c.format, err = c.loadFile(ctx, dst, src)
+	}
+	if errors.Is(err, ErrConfigFileNotFound) && (c.EnvOverrides || c.DefaultData != nil) {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+
+	if c.EnvOverrides {
+		err = c.applyEnv(dst, src)
+		if err != nil {
+			return
+		}
+	}
+
+	if c.Validate {
+		err = validate(dst, c.tag(), src)
+	}
+	return
+}
+
+// loadFile decodes the config returned by URI() into dst and records it as
+// the origin of all values in src. It returns the format the config was
+// decoded with.
+func (c Config) loadFile(ctx context.Context, dst interface{}, src Provenance) (format *FileFormat, err error) {
+	found, err := c.resolve()
+	if err != nil {
+		return
+	}
+
+	if reflect.ValueOf(dst).Kind() != reflect.Ptr {
+		err = ErrNotAPointer
+		return
+	}
 
-	if cfgPath == "" {
-		err = ErrConfigFileNotFound
+	data, contentType, err := c.fetch(ctx, found.uri.String())
+	if err != nil {
+		return
+	}
+
+	format = c.detectFormat(found, contentType, data)
+	if format.Unmarshaller == nil {
+		err = ErrNilUnmarshaller
+		return
+	}
+	// errors and origins name the config without its password
+	origin := found.uri.Redacted()
+	err = c.unmarshal(format, data, dst)
+	if err != nil {
+		err = parseError(origin, data, err)
+		return
+	}
+
+	src[""] = Origin{URI: origin}
+	if c.provenance != nil {
+		// the origin of every key is only needed for provenance, which is
+		// worth decoding the config a second time
+		tree, treeErr := unmarshalTree(format.Unmarshaller, data)
+		if treeErr == nil {
+			src.record(nil, tree, origin, c.locate(format, data))
+		}
+	}
+	return
+}
+
+// layers returns the available configs in order of increasing precedence:
+// the search path candidates that exist, by default the system configs and
+// the user config, then the URI in EnvVar() if it is set. The search path
+// candidates that don't exist are returned in rejected.
+func (c Config) layers() (layers []candidate, rejected []Candidate, err error) {
+	existing, rejected, err := c.lookup()
+	if err != nil {
+		return
+	}
+	for i := len(existing) - 1; i >= 0; i-- {
+		layers = append(layers, existing[i])
+	}
+
+	c.traceEnv()
+	uri, err := c.envURI()
+	if uri != nil {
+		layers = append(layers, candidate{uri: uri, format: c.formatOf(uri.Path, c.defaultFormat())})
+	}
+	for _, l := range layers {
+		c.trace("config: layer", "uri", l.uri.Redacted())
+	}
+	return
+}
+
+// defaultDataOrigin is the origin recorded for values from DefaultData.
+const defaultDataOrigin = "default"
+
+// loadTree reads every layer into a format-neutral tree and deep-merges them,
+// recording the layer each value was taken from in src. It returns the
+// format of the layer with the highest precedence.
+func (c Config) loadTree(ctx context.Context, src Provenance) (tree map[string]interface{}, format *FileFormat, err error) {
+	layers, rejected, err := c.layers()
+	if err != nil {
+		return
+	}
+	if len(layers) == 0 && c.DefaultData == nil {
+		err = &NotFoundError{Candidates: rejected}
+		return
+	}
+
+	tree = make(map[string]interface{})
+	if c.DefaultData != nil {
+		tree, err = unmarshalTree(c.defaultFormat().Unmarshaller, c.DefaultData)
+		if err != nil {
+			err = parseError(defaultDataOrigin, c.DefaultData, err)
+			return
+		}
+		src.record(nil, tree, defaultDataOrigin, c.locate(c.defaultFormat(), c.DefaultData))
+	}
+
+	for _, l := range layers {
+		p, origin := l.uri.String(), l.uri.Redacted()
+		if l.uri.Scheme == "file" {
+			p, origin = l.uri.Path, l.uri.Path
+		}
+
+		var data []byte
+		var contentType string
+		data, contentType, err = c.fetch(ctx, p)
+		if err != nil {
+			return
+		}
+
+		format = c.detectFormat(l, contentType, data)
+		var layer map[string]interface{}
+		layer, err = unmarshalTree(format.Unmarshaller, data)
+		if err != nil {
+			err = parseError(origin, data, err)
+			return
+		}
+		mergeTree(tree, layer)
+		src.record(nil, layer, origin, c.locate(format, data))
+	}
+	return
+}
+
+// loadLayered decodes the merged tree of all layers into dst, honoring the
+// struct tags of the format of the layer with the highest precedence, which
+// it returns.
+func (c Config) loadLayered(ctx context.Context, dst interface{}, src Provenance) (format *FileFormat, err error) {
+	if reflect.ValueOf(dst).Kind() != reflect.Ptr {
+		err = ErrNotAPointer
+		return
+	}
+
+	tree, format, err := c.loadTree(ctx, src)
+	if err != nil {
 		return
 	}
 
-	err = load(c.FileFormat.Unmarshaller, cfgPath, dst)
+	c.format = format
+	d := &decoder{tags: []string{c.tag()}, fuzzy: c.ConfigTags}
+	err = d.decode(nil, tree, reflect.ValueOf(dst))
 	return
 }
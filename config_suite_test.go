@@ -1,6 +1,13 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -11,3 +18,60 @@ func TestConfig(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Config Suite")
 }
+
+// xdgVars are cleared for every spec and restored afterwards, so the XDG
+// directories of the environment running the tests don't leak into the
+// config hierarchy.
+var (
+	xdgVars  = []string{XDGConfigHome, XDGConfigDirs}
+	savedXDG map[string]string
+)
+
+var _ = BeforeEach(func() {
+	savedXDG = make(map[string]string)
+	for _, name := range xdgVars {
+		if value, ok := os.LookupEnv(name); ok {
+			savedXDG[name] = value
+		}
+		Ω(os.Unsetenv(name)).Should(BeNil())
+	}
+})
+
+var _ = AfterEach(func() {
+	for _, name := range xdgVars {
+		if value, ok := savedXDG[name]; ok {
+			Ω(os.Setenv(name, value)).Should(BeNil())
+		} else {
+			Ω(os.Unsetenv(name)).Should(BeNil())
+		}
+	}
+})
+
+// newTestDir returns a new temporary directory, to be removed by the spec.
+func newTestDir() string {
+	dir, err := ioutil.TempDir("", "config_test")
+	Ω(err).Should(BeNil())
+	return dir
+}
+
+// newTestConfig returns a yaml Config for the test service confined to dir:
+// the home directory is dir/home and the system base dir/etc.
+func newTestConfig(dir string) Config {
+	home := filepath.Join(dir, "home")
+	return Config{
+		Organization: organization,
+		Service:      service,
+		FileFormat:   &FileFormat{Extension: yamlExtension, Unmarshaller: yaml.Unmarshal},
+		pathExpander: func(p string) string {
+			p = strings.TrimPrefix(strings.TrimPrefix(p, "$HOME"), "~")
+			return filepath.Join(home, p)
+		},
+		systemBase: filepath.Join(dir, "etc"),
+	}
+}
+
+// writeTestFile writes data to path, creating missing directories.
+func writeTestFile(path, data string) {
+	Ω(os.MkdirAll(filepath.Dir(path), 0755)).Should(BeNil())
+	Ω(ioutil.WriteFile(path, []byte(data), 0640)).Should(BeNil())
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -8,6 +10,7 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -117,6 +120,62 @@ var _ = Describe("Config", func() {
 		Ω(cfg.userURI().Path).Should(Equal(filepath.Join(testHomeDir, ".config", organization, service, "config.yaml")))
 	})
 
+	Describe("URI", func() {
+		AfterEach(func() {
+			Ω(os.Unsetenv(correctEnvVar)).Should(BeNil())
+		})
+
+		It("returns ErrConfigFileNotFound without any config", func() {
+			cfg.systemBase = filepath.Join(tmpDir, "etc")
+			_, err := cfg.URI()
+			Ω(errors.Is(err, ErrConfigFileNotFound)).Should(BeTrue())
+			Ω(cfg.Path()).Should(BeEmpty())
+		})
+
+		It("accepts any registered scheme in the env var", func() {
+			Ω(os.Setenv(correctEnvVar, "https://config.internal/svc.yaml")).Should(BeNil())
+			uri, err := cfg.URI()
+			Ω(err).Should(BeNil())
+			Ω(uri.String()).Should(Equal("https://config.internal/svc.yaml"))
+			Ω(cfg.Path()).Should(Equal("https://config.internal/svc.yaml"))
+		})
+
+		It("rejects unsupported schemes in the env var", func() {
+			Ω(os.Setenv(correctEnvVar, "gopher://config.internal/svc.yaml")).Should(BeNil())
+			_, err := cfg.URI()
+			Ω(errors.Is(err, ErrUnsupportedScheme)).Should(BeTrue())
+			Ω(err.Error()).Should(ContainSubstring(correctEnvVar))
+		})
+
+		It("reports env var configs that can't be fetched", func() {
+			cfg.systemBase = filepath.Join(tmpDir, "etc")
+			userPath := cfg.userURI().Path
+			Ω(os.MkdirAll(filepath.Dir(userPath), 0755)).Should(BeNil())
+			Ω(ioutil.WriteFile(userPath, []byte(testConfigData), 0640)).Should(BeNil())
+
+			missing := filepath.Join(tmpDir, "missing.yaml")
+			Ω(os.Setenv(correctEnvVar, missing)).Should(BeNil())
+			Ω(cfg.Path()).Should(Equal(missing))
+
+			err := cfg.Load(new(configData))
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+
+		It("loads configs over http from the env var", func() {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := fmt.Fprint(w, testConfigData)
+				Ω(err).Should(BeNil())
+			}))
+			defer ts.Close()
+			Ω(os.Setenv(correctEnvVar, ts.URL+"/config.yaml")).Should(BeNil())
+
+			td := new(configData)
+			err := cfg.Load(td)
+			Ω(err).Should(BeNil())
+			Ω(td).Should(Equal(testConfigDataUnmarshalled))
+		})
+	})
+
 	Describe("Loader", func() {
 		var (
 			ts *httptest.Server
@@ -142,32 +201,76 @@ var _ = Describe("Config", func() {
 		})
 
 		It("parses http correctly", func() {
-			data, parseErr := uriParser(ts.URL)
+			data, _, parseErr := cfg.fetch(context.Background(), ts.URL)
 			Ω(parseErr).Should(BeNil())
 			Ω(data).Should(Equal([]byte(testConfigData)))
 		})
 
 		It("parses file correctly", func() {
-			data, parseErr := uriParser(f.Name())
+			data, _, parseErr := cfg.fetch(context.Background(), f.Name())
 			Ω(parseErr).Should(BeNil())
 			Ω(data).Should(Equal([]byte(testConfigData)))
 		})
 
 		It("checks to see if unmarshaller is set correctly", func() {
+			Ω(os.Setenv(correctEnvVar, f.Name())).Should(BeNil())
+			defer os.Unsetenv(correctEnvVar)
+			cfg.FileFormat.Unmarshaller = nil
+
 			td := new(configData)
-			err := load(nil, f.Name(), td)
+			err := cfg.LoadContext(context.Background(), td)
 			Ω(err).Should(Equal(ErrNilUnmarshaller))
 		})
 
 		It("checks to make sure dst is a pointer", func() {
+			Ω(os.Setenv(correctEnvVar, f.Name())).Should(BeNil())
+			defer os.Unsetenv(correctEnvVar)
+
 			td := new(configData)
-			err := load(yaml.Unmarshal, f.Name(), *td)
+			err := cfg.LoadContext(context.Background(), *td)
 			Ω(err).Should(Equal(ErrNotAPointer))
 		})
 
+		It("stops fetching when the context is done", func() {
+			hung := make(chan struct{})
+			slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-hung
+			}))
+			defer slow.Close()
+			defer close(hung)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			_, _, err := cfg.fetch(ctx, slow.URL)
+			Ω(errors.Is(err, context.DeadlineExceeded)).Should(BeTrue())
+
+			ctx, cancel = context.WithCancel(context.Background())
+			cancel()
+			_, _, err = cfg.fetch(ctx, f.Name())
+			Ω(err).Should(Equal(context.Canceled))
+		})
+
+		It("loads with a context", func() {
+			Ω(os.Setenv(correctEnvVar, f.Name())).Should(BeNil())
+			defer os.Unsetenv(correctEnvVar)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := cfg.LoadContext(ctx, new(configData))
+			Ω(err).Should(Equal(context.Canceled))
+
+			td := new(configData)
+			err = cfg.LoadContext(context.Background(), td)
+			Ω(err).Should(BeNil())
+			Ω(td).Should(Equal(testConfigDataUnmarshalled))
+		})
+
 		It("loads config data", func() {
+			Ω(os.Setenv(correctEnvVar, f.Name())).Should(BeNil())
+			defer os.Unsetenv(correctEnvVar)
+
 			td := new(configData)
-			err := load(yaml.Unmarshal, f.Name(), td)
+			err := cfg.LoadContext(context.Background(), td)
 			Ω(err).Should(BeNil())
 			Ω(td).Should(Equal(testConfigDataUnmarshalled))
 		})
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type unifiedConfig struct {
+	MaxConns int
+	Host     string `config:"db_host"`
+	Auth     struct {
+		UserName string
+	} `config:"auth"`
+}
+
+var _ = Describe("ConfigTags", func() {
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+		cfg.ConfigTags = true
+	})
+
+	AfterEach(func() {
+		Ω(os.Unsetenv("TESTORG_TESTSERVICE_DB_HOST")).Should(BeNil())
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	expected := func() *unifiedConfig {
+		v := &unifiedConfig{MaxConns: 5, Host: "db0"}
+		v.Auth.UserName = "root"
+		return v
+	}
+
+	formats := []struct {
+		format *FileFormat
+		data   string
+	}{
+		{&FileFormat{Extension: "yaml", Unmarshaller: yaml.Unmarshal},
+			"max_conns: 5\ndb_host: db0\nauth:\n  user-name: root\n"},
+		{&FileFormat{Extension: "json", Unmarshaller: json.Unmarshal},
+			`{"maxConns": 5, "db_host": "db0", "auth": {"userName": "root"}}`},
+		{&FileFormat{Extension: "toml", Unmarshaller: toml.Unmarshal},
+			"MaxConns = 5\ndb_host = \"db0\"\n[auth]\nuser_name = \"root\"\n"},
+	}
+	for _, f := range formats {
+		f := f
+		It("decodes "+f.format.Extension+" with one set of tags", func() {
+			cfg.FileFormat = f.format
+			writeTestFile(cfg.systemURI().Path, f.data)
+
+			dst := new(unifiedConfig)
+			Ω(cfg.Load(dst)).Should(BeNil())
+			Ω(dst).Should(Equal(expected()))
+
+			cfg.Layered = true
+			dst = new(unifiedConfig)
+			Ω(cfg.Load(dst)).Should(BeNil())
+			Ω(dst).Should(Equal(expected()))
+		})
+	}
+
+	It("names defaults, environment variables and saved keys by config tag", func() {
+		cfg.FileFormat = &FileFormat{Extension: "json", Unmarshaller: json.Unmarshal, Marshaller: json.Marshal}
+		cfg.DefaultData = []byte(`{"max-conns": 5}`)
+		cfg.EnvOverrides = true
+		Ω(os.Setenv("TESTORG_TESTSERVICE_DB_HOST", "db9")).Should(BeNil())
+
+		dst := new(unifiedConfig)
+		Ω(cfg.Load(dst)).Should(BeNil())
+		Ω(dst.MaxConns).Should(Equal(5))
+		Ω(dst.Host).Should(Equal("db9"))
+
+		Ω(cfg.Save(dst, SystemLevel)).Should(BeNil())
+		data, err := ioutil.ReadFile(cfg.systemURI().Path)
+		Ω(err).Should(BeNil())
+		Ω(string(data)).Should(Equal(`{"MaxConns":5,"auth":{"UserName":""},"db_host":"db9"}`))
+	})
+})
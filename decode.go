@@ -0,0 +1,512 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// structField is an exported struct field along with the config key it maps
+// to.
+type structField struct {
+	reflect.StructField
+
+	// key the field is known as in config files, the tag name if one is set,
+	// otherwise the field name
+	key string
+
+	// inline is set for embedded structs whose fields are promoted into the
+	// parent
+	inline bool
+
+	// omitempty is set for fields left out of encoded trees when empty
+	omitempty bool
+}
+
+// parseTag splits a struct tag value such as "name,omitempty" into the name
+// and its options.
+func parseTag(tag string) (name string, opts []string) {
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	opts = parts[1:]
+	return
+}
+
+// fieldsOf returns the exported fields of struct type t, with keys taken
+// from the first of tags set on each field. Fields tagged "-" are skipped.
+func fieldsOf(t reflect.Type, tags ...string) (fields []structField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		sf := structField{StructField: f, key: f.Name}
+		var named bool
+		for _, tag := range tags {
+			value, ok := f.Tag.Lookup(tag)
+			if !ok {
+				continue
+			}
+			name, opts := parseTag(value)
+			if name == "-" && len(opts) == 0 {
+				sf.key = ""
+				break
+			}
+			if name != "" {
+				sf.key = name
+				named = true
+			}
+			for _, opt := range opts {
+				switch opt {
+				case "inline", "squash":
+					sf.inline = true
+				case "omitempty":
+					sf.omitempty = true
+				}
+			}
+			break
+		}
+		if sf.key == "" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && !named && ft.Kind() == reflect.Struct {
+			sf.inline = true
+		}
+		if f.PkgPath != "" && !sf.inline {
+			continue
+		}
+		fields = append(fields, sf)
+	}
+	return
+}
+
+// lookupKey finds key in m, falling back to a case-insensitive match.
+func lookupKey(m map[string]interface{}, key string) (v interface{}, ok bool) {
+	if v, ok = m[key]; ok {
+		return
+	}
+	for k, val := range m {
+		if strings.EqualFold(k, key) {
+			return val, true
+		}
+	}
+	return
+}
+
+// lookupFuzzyKey finds key in m ignoring case, underscores and dashes, so
+// snake, kebab and camel case spellings of a key match each other. The
+// first match in sorted order wins if several keys do.
+func lookupFuzzyKey(m map[string]interface{}, key string) (v interface{}, ok bool) {
+	want := fuzzyKey(key)
+	var match string
+	for k, val := range m {
+		if fuzzyKey(k) == want && (!ok || k < match) {
+			v, ok, match = val, true, k
+		}
+	}
+	return
+}
+
+var fuzzyKeyReplacer = strings.NewReplacer("_", "", "-", "")
+
+func fuzzyKey(key string) string {
+	return strings.ToLower(fuzzyKeyReplacer.Replace(key))
+}
+
+// decoder maps a format-neutral tree, as produced by unmarshalTree, onto a Go
+// value.
+type decoder struct {
+	// struct tags consulted for key names in order, i.e. "yaml"
+	tags []string
+
+	// fuzzy matches keys ignoring underscores and dashes as a last resort
+	fuzzy bool
+}
+
+func pathString(path []string) string {
+	return strings.Join(path, ".")
+}
+
+func (d *decoder) decode(path []string, src interface{}, dst reflect.Value) (err error) {
+	if src == nil {
+		return
+	}
+
+	if s, ok := src.(string); ok && dst.Kind() == reflect.Ptr {
+		// pointer types such as *regexp.Regexp have hooks of their own
+		if _, hooked := lookupDecodeHook(dst.Type()); hooked {
+			err = d.decodeScalar(path, s, dst)
+			return
+		}
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		err = d.decode(path, src, dst.Elem())
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() == 0 {
+			dst.Set(reflect.ValueOf(src))
+			return
+		}
+	case reflect.Struct:
+		if m, ok := src.(map[string]interface{}); ok {
+			err = d.decodeStruct(path, m, dst)
+			return
+		}
+	case reflect.Map:
+		if m, ok := src.(map[string]interface{}); ok {
+			err = d.decodeMap(path, m, dst)
+			return
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := src.([]interface{}); ok {
+			err = d.decodeSlice(path, s, dst)
+			return
+		}
+	}
+
+	err = d.decodeScalar(path, src, dst)
+	return
+}
+
+func (d *decoder) decodeStruct(path []string, m map[string]interface{}, dst reflect.Value) (err error) {
+	for _, f := range fieldsOf(dst.Type(), d.tags...) {
+		fv := dst.FieldByIndex(f.Index)
+		if f.inline {
+			err = d.decode(path, m, fv)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		v, ok := lookupKey(m, f.key)
+		if !ok && d.fuzzy {
+			v, ok = lookupFuzzyKey(m, f.key)
+		}
+		if !ok {
+			continue
+		}
+		err = d.decode(append(path[:len(path):len(path)], f.key), v, fv)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (d *decoder) decodeMap(path []string, m map[string]interface{}, dst reflect.Value) (err error) {
+	t := dst.Type()
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(t, len(m)))
+	}
+
+	for k, v := range m {
+		key := reflect.New(t.Key()).Elem()
+		err = setString(key, k)
+		if err != nil {
+			err = fmt.Errorf("config: invalid key %q at %q: %v", k, pathString(path), err)
+			return
+		}
+
+		elem := reflect.New(t.Elem()).Elem()
+		if existing := dst.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		err = d.decode(append(path[:len(path):len(path)], k), v, elem)
+		if err != nil {
+			return
+		}
+		dst.SetMapIndex(key, elem)
+	}
+	return
+}
+
+func (d *decoder) decodeSlice(path []string, s []interface{}, dst reflect.Value) (err error) {
+	if dst.Kind() == reflect.Array {
+		if len(s) > dst.Len() {
+			err = fmt.Errorf("config: %d values do not fit in %s at %q", len(s), dst.Type(), pathString(path))
+			return
+		}
+	} else {
+		dst.Set(reflect.MakeSlice(dst.Type(), len(s), len(s)))
+	}
+
+	for i, v := range s {
+		err = d.decode(append(path[:len(path):len(path)], strconv.Itoa(i)), v, dst.Index(i))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// redecodeText decodes the strings of src into the values of dst whose types
+// are decoded by decodesText, leaving the rest of dst as it is. It fixes up
+// a destination decoded by an Unmarshaller that doesn't know decode hooks.
+func (d *decoder) redecodeText(path []string, src interface{}, dst reflect.Value) (err error) {
+	t := dst.Type()
+	if src == nil || !decodesTextWithin(t) {
+		return
+	}
+	if decodesText(t) {
+		if s, ok := src.(string); ok {
+			err = d.decodeScalar(path, s, dst)
+		}
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(t.Elem()))
+		}
+		err = d.redecodeText(path, src, dst.Elem())
+	case reflect.Struct:
+		m, ok := src.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, f := range fieldsOf(t, d.tags...) {
+			fv := dst.FieldByIndex(f.Index)
+			if f.inline {
+				err = d.redecodeText(path, m, fv)
+			} else if v, ok := lookupKey(m, f.key); ok {
+				err = d.redecodeText(append(path[:len(path):len(path)], f.key), v, fv)
+			}
+			if err != nil {
+				return
+			}
+		}
+	case reflect.Map:
+		m, ok := src.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(t, len(m)))
+		}
+		for k, v := range m {
+			key := reflect.New(t.Key()).Elem()
+			if setString(key, k) != nil {
+				continue
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			if existing := dst.MapIndex(key); existing.IsValid() {
+				elem.Set(existing)
+			}
+			err = d.redecodeText(append(path[:len(path):len(path)], k), v, elem)
+			if err != nil {
+				return
+			}
+			dst.SetMapIndex(key, elem)
+		}
+	case reflect.Slice, reflect.Array:
+		s, ok := src.([]interface{})
+		if !ok {
+			return
+		}
+		if dst.Kind() == reflect.Slice && dst.Len() != len(s) {
+			dst.Set(reflect.MakeSlice(t, len(s), len(s)))
+		}
+		for i := 0; i < len(s) && i < dst.Len(); i++ {
+			err = d.redecodeText(append(path[:len(path):len(path)], strconv.Itoa(i)), s[i], dst.Index(i))
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (d *decoder) decodeScalar(path []string, src interface{}, dst reflect.Value) (err error) {
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return
+	}
+
+	if s, ok := src.(string); ok {
+		err = setString(dst, s)
+		if err != nil {
+			err = fmt.Errorf("config: %q: %v", pathString(path), err)
+		}
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		switch sv.Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			dst.SetString(fmt.Sprint(src))
+			return
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := toInt64(sv); ok && !dst.OverflowInt(i) {
+			dst.SetInt(i)
+			return
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i, ok := toInt64(sv); ok && i >= 0 && !dst.OverflowUint(uint64(i)) {
+			dst.SetUint(uint64(i))
+			return
+		}
+	case reflect.Float32, reflect.Float64:
+		switch sv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			dst.SetFloat(float64(sv.Int()))
+			return
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			dst.SetFloat(float64(sv.Uint()))
+			return
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(sv.Float())
+			return
+		}
+	}
+
+	if sv.Type().ConvertibleTo(dst.Type()) && sv.Kind() == dst.Kind() {
+		dst.Set(sv.Convert(dst.Type()))
+		return
+	}
+
+	err = fmt.Errorf("config: cannot decode %T into %s at %q", src, dst.Type(), pathString(path))
+	return
+}
+
+// toInt64 converts integral numeric values to int64.
+func toInt64(v reflect.Value) (i int64, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := v.Uint()
+		return int64(u), u <= 1<<63-1
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return int64(f), f == float64(int64(f))
+	}
+	return
+}
+
+// setString parses s into v according to v's type, with its decode hook if
+// it has one. Slices are parsed from comma separated lists and maps from
+// comma separated key=value pairs.
+func setString(v reflect.Value, s string) (err error) {
+	handled, err := decodeText(v, s)
+	if handled {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		err = setString(v.Elem(), s)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			err = fmt.Errorf("cannot parse %q as %s", s, v.Type())
+			return
+		}
+		v.Set(reflect.ValueOf(s))
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		if err != nil {
+			return
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return
+		}
+		parts := splitList(s)
+		sl := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			err = setString(sl.Index(i), part)
+			if err != nil {
+				return
+			}
+		}
+		v.Set(sl)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for _, pair := range splitList(s) {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				err = fmt.Errorf("invalid map entry %q, expected key=value", pair)
+				return
+			}
+			key := reflect.New(v.Type().Key()).Elem()
+			err = setString(key, strings.TrimSpace(kv[0]))
+			if err != nil {
+				return
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			err = setString(elem, strings.TrimSpace(kv[1]))
+			if err != nil {
+				return
+			}
+			m.SetMapIndex(key, elem)
+		}
+		v.Set(m)
+	default:
+		err = fmt.Errorf("cannot parse %q as %s", s, v.Type())
+	}
+	return
+}
+
+// splitList splits a comma separated list, trimming whitespace around each
+// item.
+func splitList(s string) (parts []string) {
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+	for _, part := range strings.Split(s, ",") {
+		parts = append(parts, strings.TrimSpace(part))
+	}
+	return
+}
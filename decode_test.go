@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type decodeEmbedded struct {
+	Name string `yaml:"name"`
+}
+
+type decodeConfig struct {
+	decodeEmbedded `yaml:",inline"`
+
+	Port     uint16          `yaml:"port"`
+	Ratio    float64         `yaml:"ratio"`
+	Enabled  bool            `yaml:"enabled"`
+	Timeout  time.Duration   `yaml:"timeout"`
+	Hosts    []string        `yaml:"hosts"`
+	Labels   map[string]int  `yaml:"labels"`
+	Nested   *decodeEmbedded `yaml:"nested"`
+	Untagged string
+	Ignored  string            `yaml:"-"`
+	Extra    map[string]string `yaml:"extra"`
+}
+
+var _ = Describe("Decoder", func() {
+	var d *decoder
+
+	BeforeEach(func() {
+		d = &decoder{tags: []string{"yaml"}}
+	})
+
+	It("decodes a tree into a struct", func() {
+		dst := new(decodeConfig)
+		err := d.decode(nil, map[string]interface{}{
+			"name":     "svc",
+			"port":     int64(8080),
+			"ratio":    1,
+			"enabled":  true,
+			"timeout":  "30s",
+			"hosts":    []interface{}{"a", "b"},
+			"labels":   map[string]interface{}{"x": 1},
+			"nested":   map[string]interface{}{"name": "inner"},
+			"untagged": "case-insensitive",
+			"Ignored":  "nope",
+		}, reflect.ValueOf(dst))
+		Ω(err).Should(BeNil())
+		Ω(dst.Name).Should(Equal("svc"))
+		Ω(dst.Port).Should(Equal(uint16(8080)))
+		Ω(dst.Ratio).Should(Equal(1.0))
+		Ω(dst.Enabled).Should(BeTrue())
+		Ω(dst.Timeout).Should(Equal(30 * time.Second))
+		Ω(dst.Hosts).Should(Equal([]string{"a", "b"}))
+		Ω(dst.Labels).Should(Equal(map[string]int{"x": 1}))
+		Ω(dst.Nested).Should(Equal(&decodeEmbedded{Name: "inner"}))
+		Ω(dst.Untagged).Should(Equal("case-insensitive"))
+		Ω(dst.Ignored).Should(BeEmpty())
+	})
+
+	It("parses strings from formats without typed values", func() {
+		dst := new(decodeConfig)
+		err := d.decode(nil, map[string]interface{}{
+			"port":    "8080",
+			"enabled": "true",
+			"hosts":   "a, b",
+			"extra":   "k=v",
+		}, reflect.ValueOf(dst))
+		Ω(err).Should(BeNil())
+		Ω(dst.Port).Should(Equal(uint16(8080)))
+		Ω(dst.Enabled).Should(BeTrue())
+		Ω(dst.Hosts).Should(Equal([]string{"a", "b"}))
+		Ω(dst.Extra).Should(Equal(map[string]string{"k": "v"}))
+	})
+
+	It("reports the path of values that do not fit", func() {
+		err := d.decode(nil, map[string]interface{}{"port": 70000}, reflect.ValueOf(new(decodeConfig)))
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring(`"port"`))
+	})
+})
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SetDefaults sets every zero-valued field of dst that has a `default:"..."`
+// struct tag to the tag's value. Values are parsed the same way as
+// environment variables: slices from comma separated lists, maps from comma
+// separated key=value pairs and other types with their decode hook, see
+// RegisterDecodeHook.
+// Nested structs are filled recursively; nil struct pointers are only
+// allocated when one of their fields has a default.
+func SetDefaults(dst interface{}) (err error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		err = ErrNotAPointer
+		return
+	}
+
+	_, err = setDefaults(nil, v.Elem())
+	return
+}
+
+// setDefaults reports whether any field of v was set. Non-struct values,
+// like maps and slices, have no fields to default and are left alone.
+func setDefaults(path []string, v reflect.Value) (set bool, err error) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for _, f := range fieldsOf(v.Type()) {
+		fv := v.FieldByIndex(f.Index)
+		fieldPath := path
+		if !f.inline {
+			fieldPath = append(path[:len(path):len(path)], f.Name)
+		}
+
+		if value, ok := f.Tag.Lookup("default"); ok && !f.inline {
+			if !fv.IsZero() {
+				continue
+			}
+			err = setString(fv, value)
+			if err != nil {
+				err = fmt.Errorf("config: invalid default for %s: %v", pathString(fieldPath), err)
+				return
+			}
+			set = true
+			continue
+		}
+
+		var fieldSet bool
+		t := fv.Type()
+		switch {
+		case decodesText(t):
+			// parsed as a whole, like url.URL
+		case t.Kind() == reflect.Struct:
+			fieldSet, err = setDefaults(fieldPath, fv)
+		case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct:
+			if !fv.IsNil() {
+				fieldSet, err = setDefaults(fieldPath, fv.Elem())
+				break
+			}
+			tmp := reflect.New(t.Elem())
+			fieldSet, err = setDefaults(fieldPath, tmp.Elem())
+			if fieldSet {
+				fv.Set(tmp)
+			}
+		}
+		if err != nil {
+			return
+		}
+		set = set || fieldSet
+	}
+	return
+}
+
+// applyDefaults copies Defaults into dst, sets tag defaults and, unless
+// layered, decodes DefaultData into dst.
+func (c Config) applyDefaults(dst interface{}) (err error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		err = ErrNotAPointer
+		return
+	}
+
+	if c.Defaults != nil {
+		defaults := reflect.ValueOf(c.Defaults)
+		if defaults.Kind() == reflect.Ptr && defaults.IsNil() {
+			err = fmt.Errorf("config: Defaults is a nil %T", c.Defaults)
+			return
+		}
+		defaults = reflect.Indirect(defaults)
+		if defaults.Type() != v.Elem().Type() {
+			err = fmt.Errorf("config: Defaults is %T, expected %s", c.Defaults, v.Elem().Type())
+			return
+		}
+		v.Elem().Set(deepCopy(defaults))
+	}
+
+	err = SetDefaults(dst)
+	if err != nil {
+		return
+	}
+
+	if c.DefaultData != nil && !c.Layered {
+		err = c.unmarshal(c.defaultFormat(), c.DefaultData, dst)
+		if err != nil {
+			err = parseError(defaultDataOrigin, c.DefaultData, err)
+			return
+		}
+		if c.provenance != nil {
+			tree, treeErr := unmarshalTree(c.defaultFormat().Unmarshaller, c.DefaultData)
+			if treeErr == nil {
+				c.provenance.record(nil, tree, defaultDataOrigin, c.locate(c.defaultFormat(), c.DefaultData))
+			}
+		}
+	}
+	return
+}
+
+// deepCopy returns a copy of v that shares no maps, slices or pointers with
+// it.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(deepCopy(v.Elem()))
+		return p
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		i := reflect.New(v.Type()).Elem()
+		i.Set(deepCopy(v.Elem()))
+		return i
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, k := range v.MapKeys() {
+			m.SetMapIndex(k, deepCopy(v.MapIndex(k)))
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return s
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if s.Field(i).CanSet() {
+				s.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type defaultsDatabase struct {
+	Host    string        `yaml:"host" default:"localhost"`
+	Port    int           `yaml:"port" default:"5432"`
+	Timeout time.Duration `yaml:"timeout" default:"10s"`
+}
+
+type defaultsConfig struct {
+	Name     string            `yaml:"name" default:"svc"`
+	Hosts    []string          `yaml:"hosts" default:"a,b"`
+	Labels   map[string]string `yaml:"labels" default:"env=dev"`
+	Database defaultsDatabase  `yaml:"database"`
+	Replica  *defaultsDatabase `yaml:"replica"`
+	Plain    *struct {
+		Value string `yaml:"value"`
+	} `yaml:"plain"`
+	Enabled bool `yaml:"enabled"`
+}
+
+var _ = Describe("Defaults", func() {
+	It("fills zero fields from default tags", func() {
+		dst := &defaultsConfig{Name: "explicit"}
+		err := SetDefaults(dst)
+		Ω(err).Should(BeNil())
+		Ω(dst.Name).Should(Equal("explicit"))
+		Ω(dst.Hosts).Should(Equal([]string{"a", "b"}))
+		Ω(dst.Labels).Should(Equal(map[string]string{"env": "dev"}))
+		Ω(dst.Database).Should(Equal(defaultsDatabase{Host: "localhost", Port: 5432, Timeout: 10 * time.Second}))
+		Ω(dst.Replica).Should(Equal(&defaultsDatabase{Host: "localhost", Port: 5432, Timeout: 10 * time.Second}))
+		Ω(dst.Plain).Should(BeNil())
+	})
+
+	It("reports invalid defaults", func() {
+		dst := &struct {
+			Port int `default:"many"`
+		}{}
+		err := SetDefaults(dst)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring("Port"))
+	})
+
+	It("checks to make sure dst is a pointer", func() {
+		Ω(SetDefaults(defaultsConfig{})).Should(Equal(ErrNotAPointer))
+	})
+
+	It("deep copies values", func() {
+		src := &defaultsConfig{Hosts: []string{"a"}, Labels: map[string]string{"k": "v"}}
+		dst := deepCopy(reflect.ValueOf(src)).Interface().(*defaultsConfig)
+		Ω(dst).Should(Equal(src))
+		dst.Hosts[0] = "b"
+		dst.Labels["k"] = "w"
+		Ω(src.Hosts[0]).Should(Equal("a"))
+		Ω(src.Labels["k"]).Should(Equal("v"))
+	})
+
+	Describe("Load", func() {
+		var (
+			cfg    Config
+			tmpDir string
+		)
+
+		BeforeEach(func() {
+			tmpDir = newTestDir()
+			cfg = newTestConfig(tmpDir)
+
+			path := cfg.systemURI().Path
+			Ω(os.MkdirAll(filepath.Dir(path), 0755)).Should(BeNil())
+			Ω(ioutil.WriteFile(path, []byte("database:\n  port: 5433\n"), 0640)).Should(BeNil())
+		})
+
+		AfterEach(func() {
+			Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+		})
+
+		for _, layered := range []bool{false, true} {
+			layered := layered
+
+			It("applies defaults before decoding", func() {
+				cfg.Layered = layered
+				dst := new(defaultsConfig)
+				err := cfg.Load(dst)
+				Ω(err).Should(BeNil())
+				Ω(dst.Database.Host).Should(Equal("localhost"))
+				Ω(dst.Database.Port).Should(Equal(5433))
+			})
+
+			It("uses a default value and default data", func() {
+				cfg.Layered = layered
+				cfg.Defaults = defaultsConfig{Enabled: true, Hosts: []string{"c"}}
+				cfg.DefaultData = []byte("name: embedded\ndatabase:\n  host: db0\n")
+
+				dst := new(defaultsConfig)
+				err := cfg.Load(dst)
+				Ω(err).Should(BeNil())
+				Ω(dst.Enabled).Should(BeTrue())
+				Ω(dst.Hosts).Should(Equal([]string{"c"}))
+				Ω(dst.Name).Should(Equal("embedded"))
+				Ω(dst.Database.Host).Should(Equal("db0"))
+				Ω(dst.Database.Port).Should(Equal(5433))
+			})
+
+			It("loads default data without any config file", func() {
+				cfg.Layered = layered
+				cfg.systemBase = filepath.Join(tmpDir, "missing")
+				cfg.DefaultData = []byte("name: embedded\n")
+
+				dst := new(defaultsConfig)
+				err := cfg.Load(dst)
+				Ω(err).Should(BeNil())
+				Ω(dst.Name).Should(Equal("embedded"))
+			})
+
+			It("loads into a map", func() {
+				cfg.Layered = layered
+				dst := make(map[string]interface{})
+				err := cfg.Load(&dst)
+				Ω(err).Should(BeNil())
+				Ω(dst).Should(HaveKey("database"))
+			})
+		}
+
+		It("rejects defaults of another type", func() {
+			cfg.Defaults = layeredConfig{}
+			err := cfg.Load(new(defaultsConfig))
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("rejects nil defaults pointers", func() {
+			cfg.Defaults = (*defaultsConfig)(nil)
+			err := cfg.Load(new(defaultsConfig))
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
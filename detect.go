@@ -0,0 +1,58 @@
+package config
+
+import (
+	"mime"
+	"strings"
+)
+
+// detectFormat returns the format of the config found with contentType and
+// data, see DetectFormat.
+func (c Config) detectFormat(found candidate, contentType string, data []byte) *FileFormat {
+	if !c.DetectFormat {
+		return found.format
+	}
+
+	if f := c.formatByMIME(contentType); f != nil {
+		return f
+	}
+	if f := c.formatOf(found.uri.Path, nil); f != nil {
+		return f
+	}
+	for _, f := range c.formats() {
+		if f != nil && f.Sniff != nil && f.Sniff(data) {
+			return f
+		}
+	}
+	return found.format
+}
+
+// formatByMIME returns the searched format with the media type of
+// contentType. Structured syntax suffixes are understood, so that
+// "application/vnd.example+json" matches "application/json".
+func (c Config) formatByMIME(contentType string) *FileFormat {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	candidates := []string{mediaType}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		candidates = append(candidates, "application/"+mediaType[i+1:])
+	}
+	for _, candidate := range candidates {
+		for _, f := range c.formats() {
+			if f == nil {
+				continue
+			}
+			for _, t := range f.MIMETypes {
+				if strings.EqualFold(t, candidate) {
+					return f
+				}
+			}
+		}
+	}
+	return nil
+}
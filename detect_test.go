@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DetectFormat", func() {
+	const jsonData = `{"has_burrito": true, "favorite_hero": "roadhog"}`
+
+	var (
+		cfg         Config
+		ts          *httptest.Server
+		contentType string
+		body        string
+
+		yamlFormat = &FileFormat{
+			Extension:    yamlExtension,
+			Unmarshaller: yaml.Unmarshal,
+			MIMETypes:    []string{"application/yaml"},
+		}
+		jsonFormat = &FileFormat{
+			Extension:    "json",
+			Unmarshaller: json.Unmarshal,
+			MIMETypes:    []string{"application/json"},
+			Sniff: func(data []byte) bool {
+				return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+			},
+		}
+	)
+
+	BeforeEach(func() {
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			_, err := w.Write([]byte(body))
+			Ω(err).Should(BeNil())
+		}))
+
+		cfg = Config{
+			Organization: organization,
+			Service:      service,
+			Formats:      []*FileFormat{yamlFormat, jsonFormat},
+			DetectFormat: true,
+		}
+	})
+
+	AfterEach(func() {
+		ts.Close()
+		Ω(os.Unsetenv(correctEnvVar)).Should(BeNil())
+	})
+
+	load := func(path string) (td *formatsConfig, err error) {
+		Ω(os.Setenv(correctEnvVar, ts.URL+path)).Should(BeNil())
+		td = new(formatsConfig)
+		err = cfg.Load(td)
+		return
+	}
+
+	It("uses the media type of the response", func() {
+		contentType, body = "application/json; charset=utf-8", jsonData
+		td, err := load("/config.yaml")
+		Ω(err).Should(BeNil())
+		Ω(td).Should(Equal(&formatsConfig{HasBurrito: true, FavoriteHero: "roadhog"}))
+	})
+
+	It("understands structured syntax suffixes", func() {
+		contentType, body = "application/vnd.podhub+json", jsonData
+		td, err := load("/config")
+		Ω(err).Should(BeNil())
+		Ω(td.FavoriteHero).Should(Equal("roadhog"))
+	})
+
+	It("falls back to the extension of the path", func() {
+		contentType, body = "text/plain", jsonData
+		td, err := load("/config.json")
+		Ω(err).Should(BeNil())
+		Ω(td.FavoriteHero).Should(Equal("roadhog"))
+	})
+
+	It("falls back to sniffing the content", func() {
+		contentType, body = "text/plain", jsonData
+		td, err := load("/config")
+		Ω(err).Should(BeNil())
+		Ω(td.FavoriteHero).Should(Equal("roadhog"))
+	})
+
+	It("falls back to the format the config was searched with", func() {
+		contentType, body = "", testConfigData
+		td, err := load("/config")
+		Ω(err).Should(BeNil())
+		Ω(td.FavoriteHero).Should(Equal("roadhog"))
+	})
+
+	It("is disabled by default", func() {
+		cfg.DetectFormat = false
+		contentType, body = "application/json", testConfigData
+		td, err := load("/config")
+		Ω(err).Should(BeNil())
+		Ω(td.FavoriteHero).Should(Equal("roadhog"))
+	})
+})
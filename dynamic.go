@@ -0,0 +1,198 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Tree is a config loaded without a Go type, made of nested
+// map[string]interface{}, []interface{} and scalar values, whatever the
+// format it was read from. Values are addressed by dotted paths, i.e.
+// "database.host", with keys matched like Load does.
+type Tree map[string]interface{}
+
+// LoadTree loads the config into a Tree, see LoadTreeContext.
+func (c Config) LoadTree() (t Tree, err error) {
+	return c.LoadTreeContext(context.Background())
+}
+
+// LoadTreeContext loads the config into a Tree, honoring Layered and
+// DefaultData like LoadContext. With EnvOverrides, environment variables
+// replace the values of the keys present in the tree, as there are no
+// struct fields to derive other names from.
+func (c Config) LoadTreeContext(ctx context.Context) (t Tree, err error) {
+	if c.defaultFormat() == nil {
+		err = ErrNilFileFormat
+		return
+	}
+
+	var tree map[string]interface{}
+	if c.Layered {
+		tree, _, err = c.loadTree(ctx, make(Provenance))
+	} else {
+		tree, err = c.loadFileTree(ctx)
+	}
+	if errors.Is(err, ErrConfigFileNotFound) && c.EnvOverrides {
+		tree, err = make(map[string]interface{}), nil
+	}
+	if err != nil {
+		return
+	}
+
+	if c.EnvOverrides {
+		applyTreeEnv(c.EnvPrefix(), nil, tree, os.LookupEnv)
+	}
+	t = Tree(tree)
+	return
+}
+
+// loadFileTree reads the config returned by URI() on top of DefaultData.
+func (c Config) loadFileTree(ctx context.Context) (tree map[string]interface{}, err error) {
+	tree = make(map[string]interface{})
+	if c.DefaultData != nil {
+		tree, err = unmarshalTree(c.defaultFormat().Unmarshaller, c.DefaultData)
+		if err != nil {
+			err = parseError(defaultDataOrigin, c.DefaultData, err)
+			return
+		}
+	}
+
+	found, err := c.resolve()
+	if errors.Is(err, ErrConfigFileNotFound) && c.DefaultData != nil {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	data, contentType, err := c.fetch(ctx, found.uri.String())
+	if err != nil {
+		return
+	}
+	format := c.detectFormat(found, contentType, data)
+	file, err := unmarshalTree(format.Unmarshaller, data)
+	if err != nil {
+		err = parseError(found.uri.String(), data, err)
+		return
+	}
+	mergeTree(tree, file)
+	return
+}
+
+// applyTreeEnv replaces the leaves of tree with the environment variables
+// named after their paths.
+func applyTreeEnv(prefix string, path []string, tree map[string]interface{}, lookup func(string) (string, bool)) {
+	for k, v := range tree {
+		keyPath := append(path[:len(path):len(path)], k)
+		if m, ok := v.(map[string]interface{}); ok {
+			applyTreeEnv(prefix, keyPath, m, lookup)
+			continue
+		}
+
+		names := make([]string, len(keyPath))
+		for i, key := range keyPath {
+			names[i] = envName(key)
+		}
+		if value, ok := lookup(prefix + strings.Join(names, EnvSeparator)); ok {
+			tree[k] = value
+		}
+	}
+}
+
+// Get returns the value at path. The empty path is the whole tree.
+func (t Tree) Get(path string) (v interface{}, ok bool) {
+	v, ok = map[string]interface{}(t), t != nil
+	if path == "" {
+		return
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, isMap := v.(map[string]interface{})
+		if !isMap {
+			return nil, false
+		}
+		v, ok = lookupKey(m, key)
+		if !ok {
+			return
+		}
+	}
+	return
+}
+
+// IsSet reports whether path has a value.
+func (t Tree) IsSet(path string) bool {
+	v, ok := t.Get(path)
+	return ok && v != nil
+}
+
+// Sub returns the subtree at path, or nil if path is not a mapping.
+func (t Tree) Sub(path string) Tree {
+	v, _ := t.Get(path)
+	m, _ := v.(map[string]interface{})
+	return Tree(m)
+}
+
+// Decode decodes the value at path into dst, which must be a pointer, like
+// Load decodes a config with ConfigTags. Struct fields are named by their
+// config, yaml, json, toml, hcl or ini tags. Nothing is decoded if path has
+// no value.
+func (t Tree) Decode(path string, dst interface{}) (err error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		err = ErrNotAPointer
+		return
+	}
+
+	src, ok := t.Get(path)
+	if !ok {
+		return
+	}
+	var keyPath []string
+	if path != "" {
+		keyPath = strings.Split(path, ".")
+	}
+	d := &decoder{tags: keyTags, fuzzy: true}
+	err = d.decode(keyPath, src, v)
+	return
+}
+
+// GetString returns the value at path as a string, or "" if it is unset or
+// can't be converted.
+func (t Tree) GetString(path string) (s string) {
+	t.Decode(path, &s)
+	return
+}
+
+// GetBool returns the value at path as a bool, or false if it is unset or
+// can't be converted.
+func (t Tree) GetBool(path string) (b bool) {
+	t.Decode(path, &b)
+	return
+}
+
+// GetInt returns the value at path as an int, or 0 if it is unset or can't
+// be converted.
+func (t Tree) GetInt(path string) (i int) {
+	t.Decode(path, &i)
+	return
+}
+
+// GetDuration returns the value at path as a time.Duration, parsed from
+// strings such as "1m30s", or 0 if it is unset or can't be converted.
+func (t Tree) GetDuration(path string) (d time.Duration) {
+	t.Decode(path, &d)
+	return
+}
+
+// GetStringSlice returns the value at path as a []string, splitting comma
+// separated strings, or nil if it is unset or can't be converted.
+func (t Tree) GetStringSlice(path string) (s []string) {
+	if t.Decode(path, &s) != nil {
+		s = nil
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tree", func() {
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+	})
+
+	AfterEach(func() {
+		Ω(os.Unsetenv("TESTORG_TESTSERVICE_DB__HOST")).Should(BeNil())
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	It("reads values by dotted path", func() {
+		writeTestFile(cfg.systemURI().Path, "debug: true\ndb:\n  host: db0\n  port: 5432\n  timeout: 1m30s\n  replicas: [db1, db2]\n")
+
+		t, err := cfg.LoadTree()
+		Ω(err).Should(BeNil())
+		Ω(t.GetString("db.host")).Should(Equal("db0"))
+		Ω(t.GetString("db.port")).Should(Equal("5432"))
+		Ω(t.GetInt("db.port")).Should(Equal(5432))
+		Ω(t.GetInt("db.host")).Should(Equal(0))
+		Ω(t.GetBool("debug")).Should(BeTrue())
+		Ω(t.GetDuration("db.timeout")).Should(Equal(90 * time.Second))
+		Ω(t.GetStringSlice("db.replicas")).Should(Equal([]string{"db1", "db2"}))
+		Ω(t.GetStringSlice("db")).Should(BeNil())
+
+		Ω(t.IsSet("db.host")).Should(BeTrue())
+		Ω(t.IsSet("db.user")).Should(BeFalse())
+		Ω(t.IsSet("debug.host")).Should(BeFalse())
+
+		db := t.Sub("db")
+		Ω(db.GetString("host")).Should(Equal("db0"))
+		Ω(t.Sub("debug")).Should(BeNil())
+		Ω(t.Sub("missing").GetString("host")).Should(BeEmpty())
+	})
+
+	It("decodes a subtree into a struct", func() {
+		writeTestFile(cfg.systemURI().Path, "db:\n  host: db0\n  port: 5432\n")
+
+		t, err := cfg.LoadTree()
+		Ω(err).Should(BeNil())
+
+		var db struct {
+			Host string `json:"host"`
+			Port int    `toml:"port"`
+		}
+		Ω(t.Decode("db", &db)).Should(BeNil())
+		Ω(db.Host).Should(Equal("db0"))
+		Ω(db.Port).Should(Equal(5432))
+
+		Ω(t.Decode("db", db)).Should(Equal(ErrNotAPointer))
+	})
+
+	It("loads every format into the same tree", func() {
+		cfg.FileFormat = &FileFormat{Extension: "json", Unmarshaller: json.Unmarshal}
+		writeTestFile(cfg.systemURI().Path, `{"db": {"host": "db0", "port": 5432, "replicas": ["db1"]}}`)
+
+		t, err := cfg.LoadTree()
+		Ω(err).Should(BeNil())
+		Ω(t.GetInt("db.port")).Should(Equal(5432))
+		Ω(t.GetStringSlice("db.replicas")).Should(Equal([]string{"db1"}))
+	})
+
+	It("honors layers, defaults and environment overrides", func() {
+		cfg.Layered = true
+		cfg.EnvOverrides = true
+		cfg.DefaultData = []byte("db:\n  user: admin\n")
+		writeTestFile(cfg.systemURI().Path, "db:\n  host: db0\n  port: 5432\n")
+		writeTestFile(cfg.userURI().Path, "db:\n  port: 5433\n")
+		Ω(os.Setenv("TESTORG_TESTSERVICE_DB__HOST", "db9")).Should(BeNil())
+
+		t, err := cfg.LoadTree()
+		Ω(err).Should(BeNil())
+		Ω(t.GetString("db.user")).Should(Equal("admin"))
+		Ω(t.GetString("db.host")).Should(Equal("db9"))
+		Ω(t.GetInt("db.port")).Should(Equal(5433))
+
+		cfg.Layered = false
+		t, err = cfg.LoadTree()
+		Ω(err).Should(BeNil())
+		Ω(t.GetString("db.user")).Should(Equal("admin"))
+		Ω(t.IsSet("db.host")).Should(BeFalse())
+	})
+
+	It("returns ErrConfigFileNotFound without any config", func() {
+		_, err := cfg.LoadTree()
+		Ω(errors.Is(err, ErrConfigFileNotFound)).Should(BeTrue())
+	})
+})
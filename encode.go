@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// EncodeTree converts v, a struct, a map with string keys or a pointer to
+// either, into a format-neutral tree. Struct fields are keyed by tag like
+// they are when decoding, "omitempty" leaves out empty fields and values
+// decoded by a decode hook or encoding.TextUnmarshaler are encoded as
+// strings, i.e. durations as "1m30s". It is the inverse of the decoding
+// done by Load for Layered configs, and allows formats without a marshaller
+// of their own to be written, i.e. HCL as JSON.
+func EncodeTree(v interface{}, tag string) (tree map[string]interface{}, err error) {
+	tree, err = encodeTree(v, tag)
+	return
+}
+
+// encodeTree is EncodeTree with keys taken from the first of tags set on
+// every field.
+func encodeTree(v interface{}, tags ...string) (tree map[string]interface{}, err error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			tree = make(map[string]interface{})
+			return
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct && rv.Kind() != reflect.Map {
+		err = fmt.Errorf("config: cannot encode %s as a tree", rv.Type())
+		return
+	}
+
+	e := &encoder{tags: tags}
+	encoded, err := e.encode(rv)
+	if err != nil {
+		return
+	}
+	tree, _ = encoded.(map[string]interface{})
+	if tree == nil {
+		tree = make(map[string]interface{})
+	}
+	return
+}
+
+// encoder maps a Go value onto a format-neutral tree.
+type encoder struct {
+	// struct tags consulted for key names, i.e. "yaml"
+	tags []string
+}
+
+func (e *encoder) encode(v reflect.Value) (out interface{}, err error) {
+	if zeroText(v) {
+		return
+	}
+	if text, ok := encodeText(v); ok {
+		out = text
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		out, err = e.encode(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		err = e.encodeStruct(v, m)
+		out = m
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var elem interface{}
+			elem, err = e.encode(iter.Value())
+			if err != nil {
+				return
+			}
+			m[fmt.Sprint(iter.Key().Interface())] = elem
+		}
+		out = m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i], err = e.encode(v.Index(i))
+			if err != nil {
+				return
+			}
+		}
+		out = s
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		err = fmt.Errorf("config: cannot encode %s", v.Type())
+	default:
+		out = v.Interface()
+	}
+	return
+}
+
+func (e *encoder) encodeStruct(v reflect.Value, m map[string]interface{}) (err error) {
+	for _, f := range fieldsOf(v.Type(), e.tags...) {
+		fv := v.FieldByIndex(f.Index)
+		if f.inline {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			err = e.encodeStruct(fv, m)
+			if err != nil {
+				return
+			}
+			continue
+		}
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+
+		var elem interface{}
+		elem, err = e.encode(fv)
+		if err != nil {
+			return
+		}
+		if elem != nil {
+			m[f.key] = elem
+		}
+	}
+	return
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EncodeTree", func() {
+	type database struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port,omitempty"`
+	}
+	type embedded struct {
+		Location string `yaml:"location"`
+	}
+	type encodeConfig struct {
+		embedded `yaml:",inline"`
+		Database *database        `yaml:"database"`
+		Replica  *database        `yaml:"replica"`
+		Timeout  time.Duration    `yaml:"timeout"`
+		Tags     map[string]int   `yaml:"tags"`
+		Hosts    []string         `yaml:"hosts"`
+		Ignored  string           `yaml:"-"`
+		Extra    map[string]bool  `yaml:"extra,omitempty"`
+		Raw      []map[string]int `yaml:"raw"`
+	}
+
+	It("encodes structs keyed by tag", func() {
+		tree, err := EncodeTree(&encodeConfig{
+			embedded: embedded{Location: "etc"},
+			Database: &database{Host: "db0"},
+			Timeout:  90 * time.Second,
+			Tags:     map[string]int{"a": 1},
+			Hosts:    []string{"a", "b"},
+			Ignored:  "ignored",
+			Raw:      []map[string]int{{"b": 2}},
+		}, "yaml")
+		Ω(err).Should(BeNil())
+		Ω(tree).Should(Equal(map[string]interface{}{
+			"location": "etc",
+			"database": map[string]interface{}{"host": "db0"},
+			"timeout":  "1m30s",
+			"tags":     map[string]interface{}{"a": 1},
+			"hosts":    []interface{}{"a", "b"},
+			"raw":      []interface{}{map[string]interface{}{"b": 2}},
+		}))
+	})
+
+	It("round trips through the decoder", func() {
+		src := &encodeConfig{Database: &database{Host: "db0", Port: 5432}, Timeout: time.Minute}
+		tree, err := EncodeTree(src, "yaml")
+		Ω(err).Should(BeNil())
+
+		dst := new(encodeConfig)
+		d := &decoder{tags: []string{"yaml"}}
+		Ω(d.decode(nil, tree, reflect.ValueOf(dst))).Should(BeNil())
+		Ω(dst).Should(Equal(src))
+	})
+
+	It("rejects values that aren't structs or maps", func() {
+		_, err := EncodeTree(42, "yaml")
+		Ω(err).Should(HaveOccurred())
+
+		tree, err := EncodeTree((*encodeConfig)(nil), "yaml")
+		Ω(err).Should(BeNil())
+		Ω(tree).Should(BeEmpty())
+	})
+})
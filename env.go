@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// EnvSeparator separates the keys of nested fields in environment variable
+// names, i.e. PODHUB_UUIDD_DATABASE__HOST for the "host" key of "database".
+const EnvSeparator = "__"
+
+// envName converts a config key to its environment variable form.
+func envName(key string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// applyEnv sets every field of dst that has a matching environment variable.
+// The origin of every value set is recorded in src if it is not nil.
+func (c Config) applyEnv(dst interface{}, src Provenance) (err error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		err = ErrNotAPointer
+		return
+	}
+
+	e := &envApplier{
+		prefix: c.EnvPrefix(),
+		tag:    c.tag(),
+		lookup: os.LookupEnv,
+		src:    src,
+	}
+	_, err = e.apply(nil, v.Elem())
+	return
+}
+
+// envApplier walks a struct and overrides its leaf fields with environment
+// variables.
+type envApplier struct {
+	// prepended to every generated variable name
+	prefix string
+
+	// struct tag consulted for key names
+	tag string
+
+	// used for mocking os.LookupEnv
+	lookup func(key string) (string, bool)
+
+	// records the variable each value was taken from, may be nil
+	src Provenance
+}
+
+// apply reports whether any field of v was set. Non-struct values have no
+// fields to look up variables for and are left alone.
+func (e *envApplier) apply(path []string, v reflect.Value) (set bool, err error) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for _, f := range fieldsOf(v.Type(), e.tag) {
+		fv := v.FieldByIndex(f.Index)
+		fieldPath := path
+		if !f.inline {
+			fieldPath = append(path[:len(path):len(path)], f.key)
+		}
+
+		var fieldSet bool
+		fieldSet, err = e.applyField(fieldPath, f, fv)
+		if err != nil {
+			return
+		}
+		set = set || fieldSet
+	}
+	return
+}
+
+func (e *envApplier) applyField(path []string, f structField, v reflect.Value) (set bool, err error) {
+	names := make([]string, len(path))
+	for i, key := range path {
+		names[i] = envName(key)
+	}
+
+	name := e.prefix + strings.Join(names, EnvSeparator)
+	if explicit := f.Tag.Get("env"); explicit != "" {
+		name = explicit
+	}
+
+	if value, ok := e.lookup(name); ok && !f.inline {
+		err = setString(v, value)
+		if err != nil {
+			err = fmt.Errorf("config: invalid value for %s: %v", name, err)
+			return
+		}
+		set = true
+		if e.src != nil {
+			e.src[pathString(path)] = Origin{URI: "env:" + name}
+		}
+		return
+	}
+
+	t := v.Type()
+	switch {
+	case decodesText(t):
+		// parsed as a whole, like url.URL
+	case t.Kind() == reflect.Struct:
+		set, err = e.apply(path, v)
+	case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct:
+		if !v.IsNil() {
+			set, err = e.apply(path, v.Elem())
+			return
+		}
+		// only allocate nested structs that end up with a value
+		tmp := reflect.New(t.Elem())
+		set, err = e.apply(path, tmp.Elem())
+		if set {
+			v.Set(tmp)
+		}
+	}
+	return
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type envDatabase struct {
+	Host    string        `yaml:"host"`
+	Port    int           `yaml:"port"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type envConfig struct {
+	Debug     bool         `yaml:"debug"`
+	Hosts     []string     `yaml:"hosts"`
+	Database  envDatabase  `yaml:"database"`
+	Replica   *envDatabase `yaml:"replica"`
+	Fallback  *envDatabase `yaml:"fallback"`
+	APIKey    string       `yaml:"api-key"`
+	Token     string       `yaml:"token" env:"SERVICE_TOKEN"`
+	Untouched string       `yaml:"untouched"`
+}
+
+var _ = Describe("Env", func() {
+	var (
+		env map[string]string
+		e   *envApplier
+	)
+
+	BeforeEach(func() {
+		env = map[string]string{}
+		e = &envApplier{
+			prefix: "TESTORG_TESTSERVICE_",
+			tag:    "yaml",
+			lookup: func(key string) (v string, ok bool) {
+				v, ok = env[key]
+				return
+			},
+		}
+	})
+
+	It("derives the prefix from Organization and Service", func() {
+		Ω(Config{Organization: organization, Service: service}.EnvPrefix()).Should(Equal("TESTORG_TESTSERVICE_"))
+		Ω(Config{Service: service}.EnvPrefix()).Should(Equal("TESTSERVICE_"))
+	})
+
+	It("overrides nested fields and converts types", func() {
+		env["TESTORG_TESTSERVICE_DEBUG"] = "true"
+		env["TESTORG_TESTSERVICE_HOSTS"] = "a,b"
+		env["TESTORG_TESTSERVICE_DATABASE__HOST"] = "db1"
+		env["TESTORG_TESTSERVICE_DATABASE__PORT"] = "5433"
+		env["TESTORG_TESTSERVICE_DATABASE__TIMEOUT"] = "5s"
+		env["TESTORG_TESTSERVICE_REPLICA__HOST"] = "db2"
+		env["TESTORG_TESTSERVICE_API_KEY"] = "secret"
+		env["SERVICE_TOKEN"] = "token"
+
+		dst := &envConfig{Untouched: "file"}
+		set, err := e.apply(nil, reflect.ValueOf(dst).Elem())
+		Ω(err).Should(BeNil())
+		Ω(set).Should(BeTrue())
+		Ω(dst.Debug).Should(BeTrue())
+		Ω(dst.Hosts).Should(Equal([]string{"a", "b"}))
+		Ω(dst.Database).Should(Equal(envDatabase{Host: "db1", Port: 5433, Timeout: 5 * time.Second}))
+		Ω(dst.Replica).Should(Equal(&envDatabase{Host: "db2"}))
+		Ω(dst.Fallback).Should(BeNil())
+		Ω(dst.APIKey).Should(Equal("secret"))
+		Ω(dst.Token).Should(Equal("token"))
+		Ω(dst.Untouched).Should(Equal("file"))
+	})
+
+	It("names the variable holding an invalid value", func() {
+		env["TESTORG_TESTSERVICE_DATABASE__PORT"] = "many"
+		_, err := e.apply(nil, reflect.ValueOf(new(envConfig)).Elem())
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring("TESTORG_TESTSERVICE_DATABASE__PORT"))
+	})
+
+	It("loads from the environment alone when EnvOverrides is set", func() {
+		Ω(os.Setenv("TESTORG_TESTSERVICE_DATABASE__HOST", "db1")).Should(BeNil())
+		defer os.Unsetenv("TESTORG_TESTSERVICE_DATABASE__HOST")
+
+		cfg := Config{
+			Organization: organization,
+			Service:      service,
+			FileFormat:   &FileFormat{Extension: yamlExtension, Unmarshaller: yaml.Unmarshal},
+			EnvOverrides: true,
+			pathExpander: func(p string) string { return os.TempDir() },
+			systemBase:   os.TempDir(),
+		}
+		dst := new(envConfig)
+		err := cfg.Load(dst)
+		Ω(err).Should(BeNil())
+		Ω(dst.Database.Host).Should(Equal("db1"))
+	})
+})
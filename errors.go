@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Candidate is a location considered for the config, along with the reason
+// it was rejected.
+type Candidate struct {
+	URI string
+	Err error
+}
+
+// NotFoundError is returned when none of the candidate configs is available.
+// It matches ErrConfigFileNotFound under errors.Is.
+type NotFoundError struct {
+	// Candidates in order of decreasing precedence
+	Candidates []Candidate
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Candidates) == 0 {
+		return ErrConfigFileNotFound.Error()
+	}
+	reasons := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		reasons[i] = fmt.Sprintf("%s: %v", c.URI, c.Err)
+	}
+	return fmt.Sprintf("%s, searched %s", ErrConfigFileNotFound, strings.Join(reasons, "; "))
+}
+
+// Is reports whether target is ErrConfigFileNotFound.
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrConfigFileNotFound
+}
+
+// ParseError is returned when a config cannot be unmarshalled. It unwraps to
+// the error of the Unmarshaller.
+type ParseError struct {
+	// URI of the config
+	URI string
+
+	// Position of the error, if the Unmarshaller reported it
+	Position
+
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("config: parsing %s: %v", Origin{URI: e.URI, Position: e.Position}, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseError wraps err, returned by an Unmarshaller for data read from uri,
+// in a *ParseError. ErrNilUnmarshaller is returned as is.
+func parseError(uri string, data []byte, err error) error {
+	if err == nil || err == ErrNilUnmarshaller {
+		return err
+	}
+	return &ParseError{URI: uri, Position: errorPosition(data, err), Err: err}
+}
+
+var (
+	// "yaml: line 3: ...", "Near line 3 (last key parsed 'a'): ..."
+	errorLine = regexp.MustCompile(`(?i)\bline (\d+)`)
+
+	// "At 3:5: ..."
+	errorLineColumn = regexp.MustCompile(`(?i)\bat (\d+):(\d+)`)
+)
+
+// errorPosition extracts the position of err in data from the error types
+// and messages of the common unmarshallers.
+func errorPosition(data []byte, err error) (pos Position) {
+	offset := int64(-1)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+	if offset >= 0 && offset <= int64(len(data)) {
+		s := string(data[:offset])
+		pos.Line = strings.Count(s, "\n") + 1
+		pos.Column = len(s) - strings.LastIndex(s, "\n")
+		return
+	}
+
+	msg := err.Error()
+	if m := errorLineColumn.FindStringSubmatch(msg); m != nil {
+		pos.Line, _ = strconv.Atoi(m[1])
+		pos.Column, _ = strconv.Atoi(m[2])
+		return
+	}
+	if m := errorLine.FindStringSubmatch(msg); m != nil {
+		pos.Line, _ = strconv.Atoi(m[1])
+	}
+	return
+}
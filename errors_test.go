@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+	})
+
+	AfterEach(func() {
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	It("lists every candidate and why it was rejected", func() {
+		Ω(os.MkdirAll(cfg.userURI().Path, 0755)).Should(BeNil())
+
+		_, err := cfg.URI()
+		Ω(errors.Is(err, ErrConfigFileNotFound)).Should(BeTrue())
+
+		var notFound *NotFoundError
+		Ω(errors.As(err, &notFound)).Should(BeTrue())
+		Ω(notFound.Candidates).Should(HaveLen(2))
+		Ω(notFound.Candidates[0].URI).Should(Equal(cfg.userURI().Path))
+		Ω(notFound.Candidates[0].Err).Should(Equal(errIsDirectory))
+		Ω(notFound.Candidates[1].URI).Should(Equal(cfg.systemURI().Path))
+		Ω(os.IsNotExist(notFound.Candidates[1].Err)).Should(BeTrue())
+		Ω(err.Error()).Should(ContainSubstring(cfg.userURI().Path + ": is a directory"))
+
+		cfg.Layered = true
+		err = cfg.Load(new(configData))
+		Ω(errors.As(err, &notFound)).Should(BeTrue())
+		Ω(notFound.Candidates).Should(HaveLen(2))
+	})
+
+	It("wraps unmarshaller errors with the source and line", func() {
+		writeTestFile(cfg.systemURI().Path, "has_burrito: true\nfavorite_hero: [roadhog\n")
+
+		err := cfg.Load(new(configData))
+		var parseErr *ParseError
+		Ω(errors.As(err, &parseErr)).Should(BeTrue())
+		Ω(parseErr.URI).Should(Equal(cfg.systemURI().String()))
+		Ω(parseErr.Line).Should(BeNumerically(">", 1))
+		Ω(err.Error()).Should(HavePrefix("config: parsing " + cfg.systemURI().String() + ":"))
+
+		cfg.Layered = true
+		err = cfg.Load(new(configData))
+		Ω(errors.As(err, &parseErr)).Should(BeTrue())
+		Ω(parseErr.URI).Should(Equal(cfg.systemURI().Path))
+	})
+
+	It("finds the position of json errors", func() {
+		data := []byte("{\n  \"a\": 1,\n  \"b\": x\n}\n")
+		err := json.Unmarshal(data, new(map[string]interface{}))
+		Ω(err).ShouldNot(BeNil())
+		Ω(errorPosition(data, err)).Should(Equal(Position{Line: 3, Column: 9}))
+
+		err = parseError("default", data, err)
+		Ω(strings.HasPrefix(err.Error(), "config: parsing default:3:9: ")).Should(BeTrue())
+	})
+})
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+)
+
+// ctxReader fails reads once its context is done.
+type ctxReader struct {
+	ctx context.Context
+	f   *os.File
+}
+
+func (r ctxReader) Read(p []byte) (n int, err error) {
+	if err = r.ctx.Err(); err != nil {
+		return
+	}
+	return r.f.Read(p)
+}
+
+// readFile reads the file at path, checking ctx between reads.
+func readFile(ctx context.Context, path string) (data []byte, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	data, err = ioutil.ReadAll(ctxReader{ctx: ctx, f: f})
+	return
+}
@@ -0,0 +1,159 @@
+package fileformat
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bsdlp/config"
+)
+
+// ErrNotEditable is returned by SetKey for formats without an Editor
+var ErrNotEditable = errors.New("fileformat: format does not support editing")
+
+// SetKey sets the key at a dotted path, such as "database.port", to value in
+// the config file at path, creating the file if it doesn't exist. The format
+// is chosen by the extension of path, and its Editor keeps comments and key
+// order of the rest of the file intact, as well as the formatting of TOML and
+// INI files. The edited file is decoded again before it replaces the old one
+// with config.WriteFile.
+func SetKey(path, key string, value interface{}) (err error) {
+	f := ByExtension(filepath.Ext(path))
+	if f == nil {
+		err = fmt.Errorf("fileformat: no format registered for %s", path)
+		return
+	}
+	if f.Editor == nil {
+		err = fmt.Errorf("%w: %s", ErrNotEditable, f.Extension)
+		return
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+
+	data, err = f.Editor(data, key, value)
+	if err != nil {
+		return
+	}
+
+	// never replace a valid file with one the format can't read back
+	if f.Unmarshaller != nil {
+		var check map[string]interface{}
+		err = f.Unmarshaller(data, &check)
+		if err != nil {
+			err = fmt.Errorf("fileformat: setting %s would leave %s invalid: %v", key, path, err)
+			return
+		}
+	}
+	err = config.WriteFile(path, data)
+	return
+}
+
+// document is config data split into lines, remembering the line ending and
+// whether the data ended with one.
+type document struct {
+	lines    []string
+	eol      string
+	trailing bool
+}
+
+func newDocument(data []byte) *document {
+	s := string(data)
+	d := &document{eol: "\n", trailing: len(s) == 0 || strings.HasSuffix(s, "\n")}
+	if strings.Contains(s, "\r\n") {
+		d.eol = "\r\n"
+	}
+	s = strings.TrimSuffix(s, "\n")
+	s = strings.TrimSuffix(s, "\r")
+	if s != "" || len(data) > 0 {
+		d.lines = strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	}
+	return d
+}
+
+func (d *document) bytes() []byte {
+	s := strings.Join(d.lines, d.eol)
+	if d.trailing && len(d.lines) > 0 {
+		s += d.eol
+	}
+	return []byte(s)
+}
+
+// insert inserts lines before line i.
+func (d *document) insert(i int, lines ...string) {
+	d.lines = append(d.lines[:i], append(append([]string(nil), lines...), d.lines[i:]...)...)
+}
+
+// remove removes the lines from i up to, but not including, j.
+func (d *document) remove(i, j int) {
+	d.lines = append(d.lines[:i], d.lines[j:]...)
+}
+
+// splitKey splits a dotted key path.
+func splitKey(key string) (parts []string, err error) {
+	parts = strings.Split(key, ".")
+	for _, p := range parts {
+		if p == "" {
+			err = fmt.Errorf("fileformat: invalid key %q", key)
+			return
+		}
+	}
+	return
+}
+
+// splitComment splits s before the first comment marker that is outside of
+// quotes and preceded by white space or starts s, returning the value without
+// trailing white space and the comment along with the white space before it.
+func splitComment(s string, markers string) (value, comment string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.IndexByte(markers, c) >= 0 && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			value = strings.TrimRight(s[:i], " \t")
+			comment = s[len(value):]
+			return
+		}
+	}
+	value = strings.TrimRight(s, " \t")
+	return
+}
+
+// editValue normalizes value for editors: durations become strings such as
+// "1m30s", and lists are returned as their elements.
+func editValue(value interface{}) (scalar interface{}, list []interface{}, isList bool) {
+	if d, ok := value.(time.Duration); ok {
+		scalar = d.String()
+		return
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 || rv.Kind() == reflect.Array {
+		isList = true
+		for i := 0; i < rv.Len(); i++ {
+			elem, _, _ := editValue(rv.Index(i).Interface())
+			list = append(list, elem)
+		}
+		return
+	}
+	scalar = value
+	return
+}
@@ -0,0 +1,102 @@
+package fileformat
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// iniSectionLine matches a section header
+	iniSectionLine = regexp.MustCompile(`^[ \t]*\[([^\]]+)\][ \t]*([;#].*)?$`)
+
+	// iniKeyLine matches a key, capturing the name and the offset of the
+	// value after the delimiter
+	iniKeyLine = regexp.MustCompile(`^[ \t]*([^\s;#\[=:][^=:]*?)[ \t]*[=:][ \t]*`)
+)
+
+// editINI implements config.Editor for INI documents. Keys of the default
+// section are set with their name, keys of other sections prefixed with the
+// section name, i.e. "database.port" for port in [database].
+func editINI(data []byte, key string, value interface{}) (out []byte, err error) {
+	path, err := splitKey(key)
+	if err != nil {
+		return
+	}
+	rendered, err := iniValue(value)
+	if err != nil {
+		return
+	}
+	section := strings.Join(path[:len(path)-1], ".")
+	name := path[len(path)-1]
+
+	d := newDocument(data)
+	start, end := 0, len(d.lines)
+	inSection := section == ""
+	found := section == ""
+	for i, line := range d.lines {
+		if m := iniSectionLine.FindStringSubmatch(line); m != nil {
+			if inSection {
+				end = i
+				break
+			}
+			if strings.TrimSpace(m[1]) == section {
+				inSection, found = true, true
+				start = i + 1
+			}
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		m := iniKeyLine.FindStringSubmatchIndex(line)
+		if m == nil || line[m[2]:m[3]] != name {
+			continue
+		}
+		_, comment := splitComment(line[m[1]:], ";#")
+		d.lines[i] = line[:m[1]] + rendered + comment
+		out = d.bytes()
+		return
+	}
+
+	if !found {
+		var lines []string
+		if len(d.lines) > 0 && strings.TrimSpace(d.lines[len(d.lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+section+"]", name+" = "+rendered)
+		d.insert(len(d.lines), lines...)
+		out = d.bytes()
+		return
+	}
+
+	insertAt := start
+	for i := start; i < end; i++ {
+		if s, _ := splitComment(d.lines[i], ";#"); strings.TrimSpace(s) != "" {
+			insertAt = i + 1
+		}
+	}
+	d.insert(insertAt, name+" = "+rendered)
+	out = d.bytes()
+	return
+}
+
+// iniValue renders lists comma-separated, as go-ini splits them.
+func iniValue(value interface{}) (rendered string, err error) {
+	scalar, list, isList := editValue(value)
+	if isList {
+		items := make([]string, len(list))
+		for i, elem := range list {
+			items[i] = fmt.Sprint(elem)
+		}
+		rendered = strings.Join(items, ",")
+	} else {
+		rendered = fmt.Sprint(scalar)
+	}
+
+	if strings.ContainsAny(rendered, "\r\n") {
+		err = fmt.Errorf("fileformat: cannot set multi-line value in place")
+	}
+	return
+}
@@ -0,0 +1,264 @@
+package fileformat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Editors", func() {
+	Describe("YAML", func() {
+		const doc = `# service config
+name: canary # the name
+
+database:
+  # primary
+  host: db0
+  port: 5432   # default port
+  replicas:
+    - db1
+    - db2
+
+tags: [a, b]
+`
+
+		edit := func(key string, value interface{}) string {
+			out, err := YAML.Editor([]byte(doc), key, value)
+			Ω(err).Should(BeNil())
+			return string(out)
+		}
+
+		It("replaces values keeping comments", func() {
+			Ω(edit("database.port", 5433)).Should(Equal(`# service config
+name: canary # the name
+database:
+  # primary
+  host: db0
+  port: 5433 # default port
+  replicas:
+    - db1
+    - db2
+tags: [a, b]
+`))
+			Ω(edit("name", "a: b")).Should(ContainSubstring(`name: 'a: b' # the name`))
+		})
+
+		It("replaces nested values keeping their style", func() {
+			Ω(edit("database.replicas", []string{"db3"})).Should(ContainSubstring("  replicas:\n    - db3\ntags"))
+			Ω(edit("tags", []string{"c"})).Should(HaveSuffix("tags: [c]\n"))
+			Ω(edit("database", "db://")).Should(Equal(`# service config
+name: canary # the name
+database: db://
+tags: [a, b]
+`))
+		})
+
+		It("adds missing keys", func() {
+			Ω(edit("database.user", "admin")).Should(ContainSubstring("    - db2\n  user: admin\ntags"))
+			Ω(edit("cache.ttl", 30*time.Second)).Should(HaveSuffix("tags: [a, b]\ncache:\n  ttl: 30s\n"))
+
+			out, err := YAML.Editor(nil, "a.b", true)
+			Ω(err).Should(BeNil())
+			Ω(string(out)).Should(Equal("a:\n  b: true\n"))
+		})
+
+		It("produces documents that still parse", func() {
+			var v map[string]interface{}
+			Ω(YAML.Unmarshaller([]byte(edit("database.replicas", []string{"db3"})), &v)).Should(BeNil())
+			Ω(v["database"]).Should(HaveKeyWithValue("replicas", []interface{}{"db3"}))
+		})
+
+		It("refuses to descend into scalars", func() {
+			_, err := YAML.Editor([]byte(doc), "name.first", "x")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("descends into flow mappings", func() {
+			out, err := YAML.Editor([]byte("database: {host: db0, port: 5432}\n"), "database.port", 5433)
+			Ω(err).Should(BeNil())
+			Ω(string(out)).Should(Equal("database: {host: db0, port: 5433}\n"))
+		})
+
+		It("overrides anchored values without changing other aliases", func() {
+			const anchored = `base: &base
+  host: db0
+  pool:
+    size: 5
+dev: *base
+prod:
+  <<: *base
+  host: db1
+`
+			decode := func(out []byte) (v map[string]map[string]interface{}) {
+				Ω(YAML.Unmarshaller(out, &v)).Should(BeNil())
+				return
+			}
+
+			out, err := YAML.Editor([]byte(anchored), "prod.pool.size", 10)
+			Ω(err).Should(BeNil())
+			Ω(string(out)).Should(ContainSubstring("  <<: *base\n"))
+			v := decode(out)
+			Ω(v["prod"]).Should(HaveKeyWithValue("pool", map[interface{}]interface{}{"size": 10}))
+			Ω(v["prod"]).Should(HaveKeyWithValue("host", "db1"))
+			Ω(v["dev"]).Should(HaveKeyWithValue("pool", map[interface{}]interface{}{"size": 5}))
+
+			out, err = YAML.Editor([]byte(anchored), "dev.host", "db2")
+			Ω(err).Should(BeNil())
+			v = decode(out)
+			Ω(v["dev"]).Should(HaveKeyWithValue("host", "db2"))
+			Ω(v["dev"]).Should(HaveKeyWithValue("pool", map[interface{}]interface{}{"size": 5}))
+			Ω(v["base"]).Should(HaveKeyWithValue("host", "db0"))
+
+			out, err = YAML.Editor([]byte(anchored), "base.host", "db3")
+			Ω(err).Should(BeNil())
+			v = decode(out)
+			Ω(v["base"]).Should(HaveKeyWithValue("host", "db3"))
+			Ω(v["dev"]).Should(HaveKeyWithValue("host", "db3"))
+			Ω(v["prod"]).Should(HaveKeyWithValue("host", "db1"))
+		})
+
+		It("edits the first of several documents", func() {
+			out, err := YAML.Editor([]byte("name: canary\n---\nname: other\n"), "name", "stable")
+			Ω(err).Should(BeNil())
+			Ω(string(out)).Should(Equal("name: stable\n---\nname: other\n"))
+		})
+	})
+
+	Describe("TOML", func() {
+		const doc = `# service config
+name = "canary" # the name
+
+[database]
+host = "db0"
+port = 5432 # default port
+replicas = [
+  "db1",
+  "db2",
+] # read only
+
+[cache]
+ttl = "1m"
+`
+
+		edit := func(key string, value interface{}) string {
+			out, err := TOML.Editor([]byte(doc), key, value)
+			Ω(err).Should(BeNil())
+			return string(out)
+		}
+
+		It("replaces values keeping comments", func() {
+			Ω(edit("database.port", 5433)).Should(ContainSubstring("port = 5433 # default port\n"))
+			Ω(edit("name", "it's")).Should(HavePrefix("# service config\nname = \"it's\" # the name\n"))
+			Ω(edit("database.replicas", []string{"db3"})).Should(ContainSubstring("replicas = [\"db3\"] # read only\n\n[cache]"))
+		})
+
+		It("adds missing keys", func() {
+			Ω(edit("database.user", "admin")).Should(ContainSubstring("] # read only\nuser = \"admin\"\n\n[cache]"))
+			Ω(edit("debug", true)).Should(HavePrefix("# service config\nname = \"canary\" # the name\ndebug = true\n"))
+			Ω(edit("log.level", "info")).Should(HaveSuffix("ttl = \"1m\"\n\n[log]\nlevel = \"info\"\n"))
+		})
+
+		It("produces documents that still parse", func() {
+			var v map[string]interface{}
+			Ω(TOML.Unmarshaller([]byte(edit("database.replicas", []string{"db3"})), &v)).Should(BeNil())
+			Ω(TOML.Unmarshaller([]byte(edit("database.user", "admin")), &v)).Should(BeNil())
+			Ω(v["database"]).Should(HaveKeyWithValue("user", "admin"))
+		})
+
+		It("refuses to overwrite tables", func() {
+			_, err := TOML.Editor([]byte(doc), "database", "x")
+			Ω(err).Should(HaveOccurred())
+			_, err = TOML.Editor([]byte(doc), "name.first", "x")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("refuses to edit arrays of tables", func() {
+			_, err := TOML.Editor([]byte("[[srv]]\nport = 1\n"), "srv.port", 2)
+			Ω(err).Should(HaveOccurred())
+			_, err = TOML.Editor([]byte("[[srv]]\nport = 1\n\n[srv.tls]\ncert = \"a\"\n"), "srv.tls.cert", "b")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("INI", func() {
+		const doc = `; service config
+name = canary
+
+[database]
+host = db0
+port : 5432 ; default port
+
+[cache]
+ttl = 1m
+`
+
+		edit := func(key string, value interface{}) string {
+			out, err := INI.Editor([]byte(doc), key, value)
+			Ω(err).Should(BeNil())
+			return string(out)
+		}
+
+		It("replaces values keeping comments", func() {
+			Ω(edit("database.port", 5433)).Should(ContainSubstring("port : 5433 ; default port\n"))
+			Ω(edit("name", "sparrow")).Should(HavePrefix("; service config\nname = sparrow\n\n[database]"))
+		})
+
+		It("adds missing keys", func() {
+			Ω(edit("database.user", "admin")).Should(ContainSubstring("; default port\nuser = admin\n\n[cache]"))
+			Ω(edit("debug", true)).Should(HavePrefix("; service config\nname = canary\ndebug = true\n"))
+			Ω(edit("log.levels", []string{"info", "warn"})).Should(HaveSuffix("ttl = 1m\n\n[log]\nlevels = info,warn\n"))
+		})
+	})
+
+	Describe("SetKey", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "fileformat_edit_test")
+			Ω(err).Should(BeNil())
+		})
+
+		AfterEach(func() {
+			Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+		})
+
+		It("edits the file in the format of its extension", func() {
+			path := filepath.Join(tmpDir, "config.yml")
+			Ω(ioutil.WriteFile(path, []byte("# comment\ndatabase:\n  port: 5432\n"), 0600)).Should(BeNil())
+
+			Ω(SetKey(path, "database.port", 5433)).Should(BeNil())
+			data, err := ioutil.ReadFile(path)
+			Ω(err).Should(BeNil())
+			Ω(string(data)).Should(Equal("# comment\ndatabase:\n  port: 5433\n"))
+		})
+
+		It("creates missing files", func() {
+			path := filepath.Join(tmpDir, "podhub", "config.toml")
+			Ω(SetKey(path, "database.port", 5433)).Should(BeNil())
+			data, err := ioutil.ReadFile(path)
+			Ω(err).Should(BeNil())
+			Ω(string(data)).Should(Equal("[database]\nport = 5433\n"))
+		})
+
+		It("leaves files alone when the edit doesn't parse", func() {
+			path := filepath.Join(tmpDir, "config.toml")
+			Ω(ioutil.WriteFile(path, []byte("name =\n"), 0600)).Should(BeNil())
+
+			Ω(SetKey(path, "port", 5433)).ShouldNot(BeNil())
+			data, err := ioutil.ReadFile(path)
+			Ω(err).Should(BeNil())
+			Ω(string(data)).Should(Equal("name =\n"))
+		})
+
+		It("fails for formats without an editor", func() {
+			err := SetKey(filepath.Join(tmpDir, "config.json"), "a", 1)
+			Ω(err).Should(MatchError(ContainSubstring(ErrNotEditable.Error())))
+			Ω(SetKey(filepath.Join(tmpDir, "config.xml"), "a", 1)).ShouldNot(BeNil())
+		})
+	})
+})
@@ -0,0 +1,279 @@
+package fileformat
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	// tomlKeyPart matches a bare or quoted key, possibly followed by a dot
+	tomlKeyPart = regexp.MustCompile(`^[ \t]*([A-Za-z0-9_-]+|"(?:[^"\\]|\\.)*"|'[^']*')[ \t]*(\.?)`)
+
+	// tomlHeaderLine matches a table or array of tables header
+	tomlHeaderLine = regexp.MustCompile(`^[ \t]*(\[\[?)(.*?)(\]\]?)[ \t]*(#.*)?$`)
+)
+
+// parseTOMLKey parses a dotted key at the start of s, returning its parts and
+// the length of s it took up.
+func parseTOMLKey(s string) (parts []string, n int, ok bool) {
+	for {
+		m := tomlKeyPart.FindStringSubmatchIndex(s[n:])
+		if m == nil {
+			return
+		}
+		part := s[n+m[2] : n+m[3]]
+		switch part[0] {
+		case '"':
+			var err error
+			part, err = strconv.Unquote(part)
+			if err != nil {
+				return
+			}
+		case '\'':
+			part = part[1 : len(part)-1]
+		}
+		parts = append(parts, part)
+		dot := m[5] > m[4]
+		n += m[1]
+		if !dot {
+			ok = true
+			return
+		}
+	}
+}
+
+// tomlTable is a table of a TOML document, from its header, or the start of
+// the document for the root table, up to the next header.
+type tomlTable struct {
+	key   []string
+	array bool
+
+	// line of the header, -1 for the root table
+	header int
+	end    int
+}
+
+// tomlEntry is a key/value pair, spanning the lines from start up to end for
+// multi-line strings and arrays.
+type tomlEntry struct {
+	key        []string
+	start, end int
+
+	// offset of the value in the first line
+	valueStart int
+}
+
+func tomlTables(d *document) (tables []tomlTable) {
+	current := tomlTable{header: -1}
+	for i := 0; i < len(d.lines); i++ {
+		if m := tomlHeaderLine.FindStringSubmatch(d.lines[i]); m != nil && len(m[1]) == len(m[3]) {
+			if key, n, ok := parseTOMLKey(m[2]); ok && strings.TrimSpace(m[2][n:]) == "" {
+				current.end = i
+				tables = append(tables, current)
+				current = tomlTable{key: key, array: m[1] == "[[", header: i}
+				continue
+			}
+		}
+		if _, ok := parseTOMLEntry(d, i); ok {
+			i = skipTOMLValue(d, i) - 1
+		}
+	}
+	current.end = len(d.lines)
+	tables = append(tables, current)
+	return
+}
+
+func parseTOMLEntry(d *document, i int) (e tomlEntry, ok bool) {
+	line := d.lines[i]
+	key, n, ok := parseTOMLKey(line)
+	if !ok {
+		return
+	}
+	rest := line[n:]
+	trimmed := strings.TrimLeft(rest, " \t")
+	if !strings.HasPrefix(trimmed, "=") {
+		ok = false
+		return
+	}
+	e.key = key
+	e.start = i
+	e.valueStart = n + len(rest) - len(trimmed) + 1
+	e.end = skipTOMLValue(d, i)
+	return
+}
+
+// skipTOMLValue returns the line after the value of the entry at line i,
+// following multi-line strings and arrays.
+func skipTOMLValue(d *document, i int) int {
+	line := d.lines[i]
+	eq := strings.IndexByte(line, '=')
+	if eq < 0 {
+		return i + 1
+	}
+	value, _ := splitComment(line[eq+1:], "#")
+	value = strings.TrimSpace(value)
+
+	for _, delim := range []string{`"""`, `'''`} {
+		if strings.HasPrefix(value, delim) && (len(value) < 6 || !strings.HasSuffix(value, delim)) {
+			for j := i + 1; j < len(d.lines); j++ {
+				if strings.Contains(d.lines[j], delim) {
+					return j + 1
+				}
+			}
+			return len(d.lines)
+		}
+	}
+
+	if strings.HasPrefix(value, "[") {
+		depth := 0
+		for j := i; j < len(d.lines); j++ {
+			s := value
+			if j > i {
+				s, _ = splitComment(d.lines[j], "#")
+			}
+			depth += strings.Count(s, "[") - strings.Count(s, "]")
+			if depth <= 0 {
+				return j + 1
+			}
+		}
+		return len(d.lines)
+	}
+	return i + 1
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasKeyPrefix(key, prefix []string) bool {
+	return len(prefix) <= len(key) && equalKeys(key[:len(prefix)], prefix)
+}
+
+// editTOML implements config.Editor for TOML documents.
+func editTOML(data []byte, key string, value interface{}) (out []byte, err error) {
+	path, err := splitKey(key)
+	if err != nil {
+		return
+	}
+	rendered, err := tomlValue(value)
+	if err != nil {
+		return
+	}
+
+	d := newDocument(data)
+	tables := tomlTables(d)
+	var parent *tomlTable
+	for ti := range tables {
+		t := &tables[ti]
+		if !hasKeyPrefix(path, t.key) {
+			continue
+		}
+		if t.array {
+			err = fmt.Errorf("fileformat: %s is in an array of tables", key)
+			return
+		}
+		if equalKeys(t.key, path[:len(path)-1]) {
+			parent = t
+		}
+		if equalKeys(t.key, path) {
+			err = fmt.Errorf("fileformat: %s is a table", key)
+			return
+		}
+
+		start := t.header + 1
+		for i := start; i < t.end; i++ {
+			e, ok := parseTOMLEntry(d, i)
+			if !ok {
+				continue
+			}
+			i = e.end - 1
+			entryKey := append(append([]string(nil), t.key...), e.key...)
+			if equalKeys(entryKey, path) {
+				line := d.lines[e.start]
+				_, comment := splitComment(line[e.valueStart:], "#")
+				if e.end > e.start+1 {
+					// the comment of a multi-line value is on its last line
+					_, comment = splitComment(d.lines[e.end-1], "#")
+				}
+				d.lines[e.start] = line[:e.valueStart] + " " + rendered + comment
+				d.remove(e.start+1, e.end)
+				out = d.bytes()
+				return
+			}
+			if hasKeyPrefix(path, entryKey) {
+				err = fmt.Errorf("fileformat: %s is not a table", strings.Join(entryKey, "."))
+				return
+			}
+		}
+	}
+
+	// the root table is the parent of top level keys
+	leaf := tomlKey(path[len(path)-1:])
+	if parent != nil {
+		insertAt := parent.header + 1
+		for i := insertAt; i < parent.end; i++ {
+			if s, _ := splitComment(d.lines[i], "#"); strings.TrimSpace(s) != "" {
+				insertAt = i + 1
+			}
+		}
+		d.insert(insertAt, leaf+" = "+rendered)
+		out = d.bytes()
+		return
+	}
+
+	var lines []string
+	if len(d.lines) > 0 && strings.TrimSpace(d.lines[len(d.lines)-1]) != "" {
+		lines = append(lines, "")
+	}
+	lines = append(lines, "["+tomlKey(path[:len(path)-1])+"]", leaf+" = "+rendered)
+	d.insert(len(d.lines), lines...)
+	out = d.bytes()
+	return
+}
+
+// tomlKey renders a dotted key, quoting parts that aren't bare keys.
+func tomlKey(path []string) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		if m := tomlKeyPart.FindStringSubmatch(p); m != nil && m[1] == p {
+			parts[i] = p
+		} else {
+			parts[i] = strconv.Quote(p)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// tomlValue renders value with the TOML encoder.
+func tomlValue(value interface{}) (rendered string, err error) {
+	scalar, list, isList := editValue(value)
+	if isList {
+		scalar = list
+	}
+
+	var buf bytes.Buffer
+	err = toml.NewEncoder(&buf).Encode(map[string]interface{}{"v": scalar})
+	if err != nil {
+		return
+	}
+	rendered = strings.TrimSuffix(buf.String(), "\n")
+	if !strings.HasPrefix(rendered, "v = ") || strings.Contains(rendered, "\n") {
+		err = fmt.Errorf("fileformat: cannot set %T in place", value)
+		return
+	}
+	rendered = strings.TrimPrefix(rendered, "v = ")
+	return
+}
@@ -0,0 +1,256 @@
+package fileformat
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// editYAML implements config.Editor for YAML documents. It edits the node
+// tree of the first document, the one Unmarshaller reads, and encodes all
+// documents again, which keeps comments and key order but not blank lines or
+// the spacing before comments.
+//
+// Keys are looked up through aliases and "<<" merge keys. Setting a key that
+// comes from them overrides it in the mapping that refers to them, so the
+// anchored mapping and its other aliases are left as they are.
+func editYAML(data []byte, key string, value interface{}) (out []byte, err error) {
+	path, err := splitKey(key)
+	if err != nil {
+		return
+	}
+	replacement, err := yamlNode(value)
+	if err != nil {
+		return
+	}
+
+	docs, err := yamlDocuments(data)
+	if err != nil {
+		return
+	}
+	if len(docs) == 0 {
+		docs = append(docs, &yaml.Node{Kind: yaml.DocumentNode})
+	}
+	if len(docs[0].Content) == 0 {
+		docs[0].Content = append(docs[0].Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	}
+
+	n := docs[0].Content[0]
+	for depth, k := range path {
+		if !yamlMapping(n) {
+			name := "document"
+			if depth > 0 {
+				name = strings.Join(path[:depth], ".")
+			}
+			err = fmt.Errorf("fileformat: %s is not a mapping", name)
+			return
+		}
+
+		last := depth == len(path)-1
+		child := yamlLookup(n, k, !last)
+		switch {
+		case child != nil && last:
+			setYAMLNode(child, replacement)
+		case last:
+			yamlAppend(n, k, replacement)
+		case child == nil:
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			yamlAppend(n, k, child)
+		}
+		n = child
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		yamlUntagMerges(doc)
+		err = enc.Encode(doc)
+		if err != nil {
+			return
+		}
+	}
+	err = enc.Close()
+	if err != nil {
+		return
+	}
+	out = buf.Bytes()
+	if bytes.Contains(data, []byte("\r\n")) {
+		out = bytes.Replace(out, []byte("\n"), []byte("\r\n"), -1)
+	}
+	return
+}
+
+// yamlDocuments decodes every document in data.
+func yamlDocuments(data []byte) (docs []*yaml.Node, err error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		doc := new(yaml.Node)
+		err = dec.Decode(doc)
+		if err == io.EOF {
+			err = nil
+			return
+		}
+		if err != nil {
+			return
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// yamlMapping turns n into a mapping that keys can be set in, returning false
+// if it holds another kind of value. Empty values become empty mappings, and
+// aliases become mappings that merge in the aliased one.
+func yamlMapping(n *yaml.Node) bool {
+	switch {
+	case n.Kind == yaml.MappingNode:
+		return true
+	case n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null":
+		*n = yaml.Node{
+			Kind:        yaml.MappingNode,
+			Tag:         "!!map",
+			Anchor:      n.Anchor,
+			HeadComment: n.HeadComment,
+			LineComment: n.LineComment,
+			FootComment: n.FootComment,
+		}
+		return true
+	case n.Kind == yaml.AliasNode && n.Alias != nil && n.Alias.Kind == yaml.MappingNode:
+		alias := *n
+		*n = yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+			Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!merge", Value: "<<"},
+				&alias,
+			},
+		}
+		return true
+	}
+	return false
+}
+
+// yamlLookup returns the value of key k in mapping m. Values merged in with
+// "<<" are only returned if descend is true, as a copy added to m, so edits
+// below them stay in m.
+func yamlLookup(m *yaml.Node, k string, descend bool) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Kind == yaml.ScalarNode && m.Content[i].Value == k {
+			return m.Content[i+1]
+		}
+	}
+	if !descend {
+		return nil
+	}
+
+	merged := yamlMerged(m, k)
+	if merged == nil {
+		return nil
+	}
+	child := yamlCopy(merged)
+	yamlAppend(m, k, child)
+	return child
+}
+
+// yamlMerged returns the value of key k in the mappings merged into m.
+func yamlMerged(m *yaml.Node, k string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].ShortTag() != "!!merge" {
+			continue
+		}
+		sources := []*yaml.Node{m.Content[i+1]}
+		if sources[0].Kind == yaml.SequenceNode {
+			sources = sources[0].Content
+		}
+		for _, source := range sources {
+			for source.Kind == yaml.AliasNode && source.Alias != nil {
+				source = source.Alias
+			}
+			if source.Kind != yaml.MappingNode {
+				continue
+			}
+			if v := yamlLookup(source, k, false); v != nil {
+				return v
+			}
+			if v := yamlMerged(source, k); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// yamlCopy deep copies n, dropping its anchors so they stay unique.
+func yamlCopy(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	c := *n
+	c.Anchor = ""
+	c.HeadComment, c.LineComment, c.FootComment = "", "", ""
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		if child.Kind == yaml.AliasNode {
+			alias := *child
+			c.Content[i] = &alias
+			continue
+		}
+		c.Content[i] = yamlCopy(child)
+	}
+	return &c
+}
+
+// yamlAppend adds key k with value v to the end of mapping m.
+func yamlAppend(m *yaml.Node, k string, v *yaml.Node) {
+	key := new(yaml.Node)
+	key.SetString(k)
+	m.Content = append(m.Content, key, v)
+}
+
+// setYAMLNode replaces the value of n with v, keeping the comments and anchor
+// of n, as well as its flow or block style if v is the same kind of
+// collection.
+func setYAMLNode(n, v *yaml.Node) {
+	set := *v
+	set.Anchor = n.Anchor
+	set.HeadComment, set.LineComment, set.FootComment = n.HeadComment, n.LineComment, n.FootComment
+	if set.Kind == n.Kind && set.Kind != yaml.ScalarNode {
+		set.Style = n.Style
+	}
+	*n = set
+}
+
+// yamlUntagMerges drops the implicit tag of "<<" merge keys below n, which
+// the encoder would otherwise write out as "!!merge <<".
+func yamlUntagMerges(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode && n.Value == "<<" && n.Tag == "!!merge" && n.Style&yaml.TaggedStyle == 0 {
+		n.Tag = ""
+	}
+	for _, child := range n.Content {
+		yamlUntagMerges(child)
+	}
+}
+
+// yamlNode encodes value, with lists normalized by editValue.
+func yamlNode(value interface{}) (n *yaml.Node, err error) {
+	scalar, list, isList := editValue(value)
+	n = new(yaml.Node)
+	if !isList {
+		err = n.Encode(scalar)
+		return
+	}
+
+	n.Kind, n.Tag = yaml.SequenceNode, "!!seq"
+	for _, elem := range list {
+		item := new(yaml.Node)
+		err = item.Encode(elem)
+		if err != nil {
+			return
+		}
+		n.Content = append(n.Content, item)
+	}
+	return
+}
@@ -0,0 +1,13 @@
+package fileformat
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFileFormat(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FileFormat Suite")
+}
@@ -6,36 +6,56 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/bsdlp/config"
 	"github.com/bsdlp/config/fileformat/ini"
-	"github.com/hashicorp/hcl"
 	"gopkg.in/yaml.v2"
 )
 
 // YAML is a FileFormat for yaml
 var YAML = &config.FileFormat{
 	Unmarshaller: yaml.Unmarshal,
+	Marshaller:   yaml.Marshal,
+	Editor:       editYAML,
 	Extension:    "yaml",
+	MIMETypes:    []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+	Sniff:        sniffYAML,
+	Locator:      locateYAML,
 }
 
 // JSON is FileFormat for json
 var JSON = &config.FileFormat{
 	Unmarshaller: json.Unmarshal,
+	Marshaller:   marshalJSON,
 	Extension:    "json",
+	MIMETypes:    []string{"application/json", "text/json"},
+	Sniff:        sniffJSON,
+	Locator:      locateJSON,
 }
 
 // TOML is FileFormat for toml
 var TOML = &config.FileFormat{
 	Unmarshaller: toml.Unmarshal,
+	Marshaller:   marshalTOML,
+	Editor:       editTOML,
 	Extension:    "toml",
+	MIMETypes:    []string{"application/toml", "text/x-toml"},
+	Sniff:        sniffTOML,
+	Locator:      locateTOML,
 }
 
 // HCL is FileFormat for hcl
 var HCL = &config.FileFormat{
-	Unmarshaller: hcl.Unmarshal,
+	Unmarshaller: unmarshalHCL,
+	Marshaller:   marshalHCL,
 	Extension:    "hcl",
+	MIMETypes:    []string{"application/hcl", "text/x-hcl"},
 }
 
 // INI is FileFormat for ini
 var INI = &config.FileFormat{
 	Unmarshaller: ini.Unmarshal,
+	Marshaller:   ini.Marshal,
+	Editor:       editINI,
 	Extension:    "ini",
+	MIMETypes:    []string{"text/x-ini", "application/x-ini"},
+	Sniff:        sniffINI,
+	Locator:      locateINI,
 }
@@ -1,6 +1,108 @@
 package ini
 
-import "github.com/go-ini/ini"
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
 
-// Unmarshal implements config.Unmarshaller for ini
-func Unmarshal(data []byte, v interface{}) error { return ini.MapTo(v, data) }
+	"github.com/go-ini/ini"
+)
+
+// Unmarshal implements config.Unmarshaller for ini. Unmarshalling into a
+// *map[string]interface{} yields keys of the default section at the top
+// level and every other section as a nested map.
+func Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(*map[string]interface{})
+	if !ok {
+		return ini.MapTo(v, data)
+	}
+
+	f, err := ini.Load(data)
+	if err != nil {
+		return err
+	}
+
+	if *m == nil {
+		*m = make(map[string]interface{})
+	}
+	for _, section := range f.Sections() {
+		keys := *m
+		if section.Name() != ini.DEFAULT_SECTION {
+			keys = make(map[string]interface{})
+			(*m)[section.Name()] = keys
+		}
+		for _, key := range section.Keys() {
+			keys[key.Name()] = key.Value()
+		}
+	}
+	return nil
+}
+
+// Marshal implements config.Marshaller for ini, the inverse of Unmarshal.
+// Structs are reflected with their ini tags, nested structs becoming
+// sections. In a map[string]interface{}, nested maps become sections and
+// other values keys of the default section.
+func Marshal(v interface{}) (data []byte, err error) {
+	f := ini.Empty()
+	if m, ok := v.(map[string]interface{}); ok {
+		err = marshalMap(f, m)
+	} else {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr {
+			// reflecting requires addressable fields
+			ptr := reflect.New(rv.Type())
+			ptr.Elem().Set(rv)
+			rv = ptr
+		}
+		err = f.ReflectFrom(rv.Interface())
+	}
+	if err != nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	_, err = f.WriteTo(&buf)
+	data = buf.Bytes()
+	return
+}
+
+func marshalMap(f *ini.File, m map[string]interface{}) (err error) {
+	var names, sections []string
+	for name, value := range m {
+		if _, ok := value.(map[string]interface{}); ok {
+			sections = append(sections, name)
+		} else {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(sections)
+
+	for _, name := range names {
+		_, err = f.Section(ini.DEFAULT_SECTION).NewKey(name, fmt.Sprint(m[name]))
+		if err != nil {
+			return
+		}
+	}
+	for _, name := range sections {
+		var section *ini.Section
+		section, err = f.NewSection(name)
+		if err != nil {
+			return
+		}
+		keys := m[name].(map[string]interface{})
+		var keyNames []string
+		for k := range keys {
+			keyNames = append(keyNames, k)
+		}
+		sort.Strings(keyNames)
+		for _, k := range keyNames {
+			_, err = section.NewKey(k, fmt.Sprint(keys[k]))
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
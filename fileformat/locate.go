@@ -0,0 +1,236 @@
+package fileformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bsdlp/config"
+)
+
+// positionAt returns the line and column of offset in data.
+func positionAt(data []byte, offset int) config.Position {
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return config.Position{Line: line, Column: column}
+}
+
+// linePosition returns the position of the first non-blank character of
+// line i.
+func linePosition(d *document, i int) config.Position {
+	line := d.lines[i]
+	return config.Position{Line: i + 1, Column: len(line) - len(strings.TrimLeft(line, " \t")) + 1}
+}
+
+// yamlEntryLine matches a block mapping entry, capturing the indentation,
+// the key and the rest of the line after the colon.
+var yamlEntryLine = regexp.MustCompile(`^( *)("(?:[^"\\]|\\.)*"|'(?:[^']|'')*'|[^\s#'"{\[\-?:][^#]*?|-[^\s#][^#]*?)[ \t]*:(?:[ \t]+(.*))?$`)
+
+// yamlLine is the block structure of a line of a YAML document.
+type yamlLine struct {
+	// content is false for blank lines, comments and document markers
+	content bool
+	indent  int
+
+	// key and value of mapping entries, entry is false for other lines such
+	// as sequence items
+	entry bool
+	key   string
+	value string
+
+	// offset of the end of the key, including the colon
+	keyEnd int
+}
+
+func parseYAMLLine(line string) (l yamlLine) {
+	trimmed := strings.TrimLeft(line, " ")
+	if trimmed == "" || trimmed[0] == '#' || trimmed == "---" || trimmed == "..." ||
+		strings.HasPrefix(trimmed, "--- ") {
+		return
+	}
+	l.content = true
+	l.indent = len(line) - len(trimmed)
+
+	m := yamlEntryLine.FindStringSubmatchIndex(line)
+	if m == nil {
+		return
+	}
+	l.entry = true
+	l.key = line[m[4]:m[5]]
+	l.keyEnd = strings.IndexByte(line[m[5]:], ':') + m[5] + 1
+	if m[6] >= 0 {
+		l.value = line[m[6]:m[7]]
+	}
+	switch l.key[0] {
+	case '"':
+		if key, err := strconv.Unquote(l.key); err == nil {
+			l.key = key
+		}
+	case '\'':
+		l.key = strings.Replace(l.key[1:len(l.key)-1], "''", "'", -1)
+	}
+	return
+}
+
+// locateYAML implements config.Locator for block style YAML documents.
+// Keys inside sequences are left out, as their paths are ambiguous.
+func locateYAML(data []byte) (positions map[string]config.Position, err error) {
+	type level struct {
+		indent   int
+		key      string
+		sequence bool
+	}
+
+	positions = make(map[string]config.Position)
+	d := newDocument(data)
+	var stack []level
+	for i, line := range d.lines {
+		l := parseYAMLLine(line)
+		if !l.content {
+			continue
+		}
+
+		if !l.entry {
+			if !strings.HasPrefix(strings.TrimLeft(line, " "), "-") {
+				continue
+			}
+			for len(stack) > 0 && stack[len(stack)-1].indent > l.indent {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, level{indent: l.indent, sequence: true})
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= l.indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, level{indent: l.indent, key: l.key})
+
+		path := make([]string, 0, len(stack))
+		for _, s := range stack {
+			if s.sequence {
+				path = nil
+				break
+			}
+			path = append(path, s.key)
+		}
+		if path != nil {
+			positions[strings.Join(path, ".")] = linePosition(d, i)
+		}
+	}
+	return
+}
+
+// locateTOML implements config.Locator for TOML documents. Keys in arrays of
+// tables are left out, as their paths are ambiguous.
+func locateTOML(data []byte) (positions map[string]config.Position, err error) {
+	positions = make(map[string]config.Position)
+	d := newDocument(data)
+	for _, t := range tomlTables(d) {
+		if t.array {
+			continue
+		}
+		for i := t.header + 1; i < t.end; i++ {
+			e, ok := parseTOMLEntry(d, i)
+			if !ok {
+				continue
+			}
+			key := append(append([]string(nil), t.key...), e.key...)
+			positions[strings.Join(key, ".")] = linePosition(d, i)
+			i = e.end - 1
+		}
+	}
+	return
+}
+
+// locateINI implements config.Locator for INI documents, naming keys like
+// editINI.
+func locateINI(data []byte) (positions map[string]config.Position, err error) {
+	positions = make(map[string]config.Position)
+	d := newDocument(data)
+	var section string
+	for i, line := range d.lines {
+		if m := iniSectionLine.FindStringSubmatch(line); m != nil {
+			section = strings.TrimSpace(m[1])
+			continue
+		}
+		m := iniKeyLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		key := m[1]
+		if section != "" {
+			key = section + "." + key
+		}
+		positions[key] = linePosition(d, i)
+	}
+	return
+}
+
+// locateJSON implements config.Locator for JSON documents. Keys inside
+// arrays are left out, as their paths are ambiguous.
+func locateJSON(data []byte) (positions map[string]config.Position, err error) {
+	positions = make(map[string]config.Position)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	err = locateJSONValue(dec, data, nil, true, positions)
+	return
+}
+
+func locateJSONValue(dec *json.Decoder, data []byte, path []string, record bool, positions map[string]config.Position) (err error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return
+	}
+
+	switch delim {
+	case '{':
+		for dec.More() {
+			tok, err = dec.Token()
+			if err != nil {
+				return
+			}
+			key, _ := tok.(string)
+			keyPath := append(path[:len(path):len(path)], key)
+			if record {
+				positions[strings.Join(keyPath, ".")] = positionAt(data, jsonStringStart(data, int(dec.InputOffset())))
+			}
+			err = locateJSONValue(dec, data, keyPath, record, positions)
+			if err != nil {
+				return
+			}
+		}
+	case '[':
+		for dec.More() {
+			err = locateJSONValue(dec, data, path, false, positions)
+			if err != nil {
+				return
+			}
+		}
+	}
+	_, err = dec.Token()
+	return
+}
+
+// jsonStringStart returns the offset of the opening quote of the string
+// ending right before end.
+func jsonStringStart(data []byte, end int) int {
+	for i := end - 2; i >= 0; i-- {
+		if data[i] != '"' {
+			continue
+		}
+		backslashes := 0
+		for j := i - 1; j >= 0 && data[j] == '\\'; j-- {
+			backslashes++
+		}
+		if backslashes%2 == 0 {
+			return i
+		}
+	}
+	return 0
+}
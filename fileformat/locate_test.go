@@ -0,0 +1,53 @@
+package fileformat
+
+import (
+	"github.com/bsdlp/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locators", func() {
+	It("locates yaml keys", func() {
+		positions, err := YAML.Locator([]byte("# comment\nname: canary\ndatabase:\n  host: db0\n  replicas:\n    - host: db1\n  port: 5432\n"))
+		Ω(err).Should(BeNil())
+		Ω(positions).Should(Equal(map[string]config.Position{
+			"name":              {Line: 2, Column: 1},
+			"database":          {Line: 3, Column: 1},
+			"database.host":     {Line: 4, Column: 3},
+			"database.replicas": {Line: 5, Column: 3},
+			"database.port":     {Line: 7, Column: 3},
+		}))
+	})
+
+	It("locates toml keys", func() {
+		positions, err := TOML.Locator([]byte("name = \"canary\"\n\n[database]\n  host = \"db0\"\nreplicas = [\n  \"db1\",\n]\nconn.port = 5432\n\n[[servers]]\nhost = \"a\"\n"))
+		Ω(err).Should(BeNil())
+		Ω(positions).Should(Equal(map[string]config.Position{
+			"name":               {Line: 1, Column: 1},
+			"database.host":      {Line: 4, Column: 3},
+			"database.replicas":  {Line: 5, Column: 1},
+			"database.conn.port": {Line: 8, Column: 1},
+		}))
+	})
+
+	It("locates ini keys", func() {
+		positions, err := INI.Locator([]byte("name = canary\n\n[database]\nhost = db0\n"))
+		Ω(err).Should(BeNil())
+		Ω(positions).Should(Equal(map[string]config.Position{
+			"name":          {Line: 1, Column: 1},
+			"database.host": {Line: 4, Column: 1},
+		}))
+	})
+
+	It("locates json keys", func() {
+		positions, err := JSON.Locator([]byte("{\n  \"name\": \"canary\",\n  \"database\": {\"h\\\"ost\": \"db0\"},\n  \"servers\": [{\"host\": \"a\"}]\n}\n"))
+		Ω(err).Should(BeNil())
+		Ω(positions).Should(Equal(map[string]config.Position{
+			"name":            {Line: 2, Column: 3},
+			"database":        {Line: 3, Column: 3},
+			"database.h\"ost": {Line: 3, Column: 16},
+			"servers":         {Line: 4, Column: 3},
+		}))
+	})
+})
@@ -0,0 +1,96 @@
+package fileformat
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/BurntSushi/toml"
+	"github.com/bsdlp/config"
+	"github.com/hashicorp/hcl"
+)
+
+// marshalJSON indents the output for the benefit of people editing it.
+func marshalJSON(v interface{}) (data []byte, err error) {
+	data, err = json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+	return
+}
+
+func marshalTOML(v interface{}) (data []byte, err error) {
+	var buf bytes.Buffer
+	err = toml.NewEncoder(&buf).Encode(v)
+	data = buf.Bytes()
+	return
+}
+
+// marshalHCL writes JSON, which HCL parses, as there is no HCL printer for
+// Go values. Keys are taken from hcl struct tags.
+func marshalHCL(v interface{}) (data []byte, err error) {
+	tree, err := config.EncodeTree(v, "hcl")
+	if err != nil {
+		return
+	}
+	data, err = marshalJSON(tree)
+	return
+}
+
+// unmarshalHCL merges the blocks hcl decodes as []map[string]interface{}
+// when unmarshalling into a *map[string]interface{}, so "database { ... }"
+// becomes a nested map like a table or mapping of the other formats.
+func unmarshalHCL(data []byte, v interface{}) (err error) {
+	m, ok := v.(*map[string]interface{})
+	if !ok {
+		err = hcl.Unmarshal(data, v)
+		return
+	}
+
+	err = hcl.Unmarshal(data, m)
+	if err != nil {
+		return
+	}
+	for k, val := range *m {
+		(*m)[k] = mergeHCLBlocks(val)
+	}
+	return
+}
+
+func mergeHCLBlocks(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []map[string]interface{}:
+		m := make(map[string]interface{})
+		for _, block := range t {
+			for k, val := range block {
+				m[k] = mergeHCLValue(m[k], mergeHCLBlocks(val))
+			}
+		}
+		return m
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = mergeHCLBlocks(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = mergeHCLBlocks(val)
+		}
+		return t
+	}
+	return v
+}
+
+// mergeHCLValue merges the blocks of a key repeated across blocks, the last
+// value winning otherwise.
+func mergeHCLValue(dst, src interface{}) interface{} {
+	dstMap, dstIsMap := dst.(map[string]interface{})
+	srcMap, srcIsMap := src.(map[string]interface{})
+	if !dstIsMap || !srcIsMap {
+		return src
+	}
+	for k, val := range srcMap {
+		dstMap[k] = mergeHCLValue(dstMap[k], val)
+	}
+	return dstMap
+}
@@ -0,0 +1,53 @@
+package fileformat
+
+import (
+	"github.com/bsdlp/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type marshalDatabase struct {
+	Host string `yaml:"host" json:"host" toml:"host" hcl:"host" ini:"host"`
+	Port int    `yaml:"port" json:"port" toml:"port" hcl:"port" ini:"port"`
+}
+
+type marshalConfig struct {
+	Name     string          `yaml:"name" json:"name" toml:"name" hcl:"name" ini:"name"`
+	Database marshalDatabase `yaml:"database" json:"database" toml:"database" hcl:"database" ini:"database"`
+}
+
+var _ = Describe("Marshallers", func() {
+	src := marshalConfig{Name: "canary", Database: marshalDatabase{Host: "db0", Port: 5432}}
+
+	for _, f := range []*config.FileFormat{YAML, JSON, TOML, HCL, INI} {
+		f := f
+		It("round trips "+f.Extension, func() {
+			data, err := f.Marshaller(src)
+			Ω(err).Should(BeNil())
+
+			var dst marshalConfig
+			Ω(f.Unmarshaller(data, &dst)).Should(BeNil())
+			Ω(dst).Should(Equal(src))
+		})
+	}
+
+	It("marshals ini maps into sections", func() {
+		data, err := INI.Marshaller(map[string]interface{}{
+			"name":     "canary",
+			"database": map[string]interface{}{"port": 5432, "host": "db0"},
+		})
+		Ω(err).Should(BeNil())
+		Ω(string(data)).Should(Equal("name = canary\n\n[database]\nhost = db0\nport = 5432\n\n"))
+	})
+
+	It("unmarshals hcl blocks into nested maps", func() {
+		var tree map[string]interface{}
+		err := HCL.Unmarshaller([]byte("name = \"canary\"\ndatabase {\n  host = \"db0\"\n}\ndatabase {\n  port = 5432\n}\n"), &tree)
+		Ω(err).Should(BeNil())
+		Ω(tree).Should(Equal(map[string]interface{}{
+			"name":     "canary",
+			"database": map[string]interface{}{"host": "db0", "port": 5432},
+		}))
+	})
+})
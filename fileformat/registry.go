@@ -0,0 +1,119 @@
+package fileformat
+
+import (
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bsdlp/config"
+)
+
+var (
+	registryMu sync.RWMutex
+
+	// registered formats in order of registration
+	registered []*config.FileFormat
+
+	// formats by lower case extension or alias
+	byName = make(map[string]*config.FileFormat)
+)
+
+func init() {
+	Register(YAML, "yml")
+	Register(JSON)
+	Register(TOML, "tml")
+	Register(HCL)
+	Register(INI)
+}
+
+// Register makes f discoverable by its extension, its MIME types and the
+// given aliases, i.e. "yml" for YAML. Names already taken by another format
+// are reassigned to f. It is safe for concurrent use.
+func Register(f *config.FileFormat, aliases ...string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	known := false
+	for _, r := range registered {
+		if r == f {
+			known = true
+			break
+		}
+	}
+	if !known {
+		registered = append(registered, f)
+	}
+
+	for _, name := range append([]string{f.Extension}, aliases...) {
+		if name = normalizeName(name); name != "" {
+			byName[name] = f
+		}
+	}
+}
+
+// ByExtension returns the registered format for a file extension with or
+// without the leading dot, such as "yml" or ".yaml", or nil if there is
+// none. Extensions are matched case-insensitively.
+func ByExtension(ext string) *config.FileFormat {
+	return ByName(ext)
+}
+
+// ByName returns the registered format named after its extension or one of
+// its aliases, such as "yaml" or "YML", or nil if there is none.
+func ByName(name string) *config.FileFormat {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return byName[normalizeName(name)]
+}
+
+// ByMIMEType returns the registered format with the media type of
+// contentType, which may carry parameters such as a charset, or nil if there
+// is none.
+func ByMIMEType(contentType string) *config.FileFormat {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, f := range registered {
+		for _, t := range f.MIMETypes {
+			if strings.EqualFold(t, mediaType) {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// All returns the registered formats in order of registration, suitable for
+// config.Config.Formats.
+func All() []*config.FileFormat {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return append([]*config.FileFormat(nil), registered...)
+}
+
+// Names returns the extensions and aliases f is registered under, in sorted
+// order.
+func Names(f *config.FileFormat) (names []string) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for name, r := range byName {
+		if r == f {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(name, "."))
+}
@@ -0,0 +1,46 @@
+package fileformat
+
+import (
+	"github.com/bsdlp/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	It("registers the builtin formats", func() {
+		Ω(All()).Should(Equal([]*config.FileFormat{YAML, JSON, TOML, HCL, INI}))
+	})
+
+	It("looks up formats by extension and alias", func() {
+		Ω(ByExtension("yml")).Should(BeIdenticalTo(YAML))
+		Ω(ByExtension(".YAML")).Should(BeIdenticalTo(YAML))
+		Ω(ByExtension("tml")).Should(BeIdenticalTo(TOML))
+		Ω(ByName("json")).Should(BeIdenticalTo(JSON))
+		Ω(ByName("xml")).Should(BeNil())
+		Ω(Names(YAML)).Should(Equal([]string{"yaml", "yml"}))
+	})
+
+	It("looks up formats by MIME type", func() {
+		Ω(ByMIMEType("application/json; charset=utf-8")).Should(BeIdenticalTo(JSON))
+		Ω(ByMIMEType("text/x-yaml")).Should(BeIdenticalTo(YAML))
+		Ω(ByMIMEType("text/plain")).Should(BeNil())
+		Ω(ByMIMEType("")).Should(BeNil())
+	})
+
+	It("registers additional formats", func() {
+		props := &config.FileFormat{Extension: "properties", MIMETypes: []string{"text/x-java-properties"}}
+		Register(props, "props")
+		defer func() {
+			registryMu.Lock()
+			defer registryMu.Unlock()
+			registered = registered[:len(registered)-1]
+			delete(byName, "properties")
+			delete(byName, "props")
+		}()
+
+		Ω(ByExtension("props")).Should(BeIdenticalTo(props))
+		Ω(ByMIMEType("text/x-java-properties")).Should(BeIdenticalTo(props))
+		Ω(All()).Should(HaveLen(6))
+	})
+})
@@ -0,0 +1,69 @@
+package fileformat
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	sectionLine = regexp.MustCompile(`^\s*\[[^\[\]]+\]\s*$`)
+	tableLine   = regexp.MustCompile(`^\s*\[\[?[^\[\]]+\]\]?\s*(#.*)?$`)
+	assignLine  = regexp.MustCompile(`^\s*[\w.\-"']+\s*=`)
+	mappingLine = regexp.MustCompile(`^[\w.\-"']+\s*:(\s|$)`)
+)
+
+// hasLine reports whether any line of data matches re.
+func hasLine(data []byte, re *regexp.Regexp) bool {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		if re.Match(s.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTOML reports whether data is a valid TOML document.
+func isTOML(data []byte) bool {
+	var v map[string]interface{}
+	return toml.Unmarshal(data, &v) == nil
+}
+
+// sniffJSON looks for the start of an object.
+func sniffJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+// sniffTOML looks for table headers or assignments in a valid TOML document.
+func sniffTOML(data []byte) bool {
+	if sniffJSON(data) || !(hasLine(data, tableLine) || hasLine(data, assignLine)) {
+		return false
+	}
+	return isTOML(data)
+}
+
+// sniffINI looks for section headers or assignments in a document that isn't
+// valid TOML, as INI values are unquoted.
+func sniffINI(data []byte) bool {
+	if sniffJSON(data) || !(hasLine(data, sectionLine) || hasLine(data, assignLine)) {
+		return false
+	}
+	return !isTOML(data)
+}
+
+// sniffYAML looks for a document marker or a top level mapping.
+func sniffYAML(data []byte) bool {
+	if sniffJSON(data) {
+		return false
+	}
+	if !bytes.HasPrefix(data, []byte("---")) && !hasLine(data, mappingLine) {
+		return false
+	}
+	var v map[string]interface{}
+	return yaml.Unmarshal(data, &v) == nil
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// keyTags are the struct tags consulted for key names when no FileFormat is
+// at hand, in order of preference.
+var keyTags = []string{ConfigTag, "yaml", "json", "toml", "hcl", "ini"}
+
+// BindFlags registers a flag on fs for every leaf field of dst, named by the
+// dotted path of its keys, i.e. "database.host". Keys are taken from the
+// config, yaml, json, toml, hcl or ini struct tag, or the lowercased field
+// name. A `flag:"name"` tag sets the full flag name explicitly, `flag:"-"`
+// skips the field, and a `usage:"..."` tag sets the help text.
+//
+// The current values of dst are used as flag defaults, so binding after
+// Config.Load shows the loaded values in -h output and gives flags precedence
+// over environment variables and files once fs is parsed. Nested struct
+// pointers are only allocated when one of their flags is set.
+func BindFlags(fs *flag.FlagSet, dst interface{}) (err error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		err = ErrNotAPointer
+		return
+	}
+
+	root := func(bool) reflect.Value { return v.Elem() }
+	err = bindFlags(fs, "", root, v.Elem().Type())
+	return
+}
+
+// LoadFlags loads dst with Load, binds its fields to fs and parses args, so
+// values are taken from flags, then environment variables, then files.
+func (c Config) LoadFlags(fs *flag.FlagSet, args []string, dst interface{}) (err error) {
+	err = c.Load(dst)
+	if err != nil {
+		return
+	}
+
+	err = BindFlags(fs, dst)
+	if err != nil {
+		return
+	}
+
+	err = fs.Parse(args)
+	return
+}
+
+// ErrFlagRedefined is returned by BindFlags when two fields map to the same
+// flag name, or a flag of that name is already defined on the FlagSet
+var ErrFlagRedefined = errors.New("config: flag redefined")
+
+// defineFlag is fs.Var, failing instead of panicking on duplicate names.
+func defineFlag(fs *flag.FlagSet, value flag.Value, name, usage string) (err error) {
+	if fs.Lookup(name) != nil {
+		err = fmt.Errorf("%w: %s", ErrFlagRedefined, name)
+		return
+	}
+	fs.Var(value, name, usage)
+	return
+}
+
+func bindFlags(fs *flag.FlagSet, prefix string, parent func(alloc bool) reflect.Value, t reflect.Type) (err error) {
+	for _, f := range fieldsOf(t, keyTags...) {
+		f := f
+		explicit := f.Tag.Get("flag")
+		if explicit == "-" {
+			continue
+		}
+
+		name := prefix
+		if !f.inline {
+			name = prefix + strings.ToLower(f.key)
+		}
+		if explicit != "" {
+			name = explicit
+		}
+
+		field := func(alloc bool) (fv reflect.Value) {
+			pv := parent(alloc)
+			if !pv.IsValid() {
+				return
+			}
+			fv = pv.FieldByIndex(f.Index)
+			return
+		}
+
+		ft := f.Type
+		if decodesText(ft) {
+			// structs such as url.URL are parsed as a whole
+			err = defineFlag(fs, &flagValue{field: field}, name, f.Tag.Get("usage"))
+			if err != nil {
+				return
+			}
+			continue
+		}
+		if ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct {
+			ptr := field
+			field = func(alloc bool) (fv reflect.Value) {
+				pv := ptr(alloc)
+				if !pv.IsValid() {
+					return
+				}
+				if pv.IsNil() {
+					if !alloc {
+						return
+					}
+					pv.Set(reflect.New(pv.Type().Elem()))
+				}
+				fv = pv.Elem()
+				return
+			}
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Struct {
+			nested := name
+			if !f.inline {
+				nested += "."
+			}
+			err = bindFlags(fs, nested, field, ft)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		if !flaggable(ft) {
+			continue
+		}
+		err = defineFlag(fs, &flagValue{field: field, isBool: ft.Kind() == reflect.Bool}, name, f.Tag.Get("usage"))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// flaggable reports whether values of t can be parsed by setString.
+func flaggable(t reflect.Type) bool {
+	if decodesText(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Ptr, reflect.Slice:
+		return flaggable(t.Elem())
+	case reflect.Map:
+		return flaggable(t.Key()) && flaggable(t.Elem())
+	}
+	return false
+}
+
+// flagValue implements flag.Value for a struct field.
+type flagValue struct {
+	// returns the field, allocating nil parent structs when alloc is set
+	field func(alloc bool) reflect.Value
+
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	if f.field == nil {
+		return ""
+	}
+	v := f.field(false)
+	if !v.IsValid() {
+		return ""
+	}
+	return formatValue(v)
+}
+
+func (f *flagValue) Set(s string) error {
+	return setString(f.field(true), s)
+}
+
+// IsBoolFlag allows boolean flags to be set without a value.
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// formatValue is the inverse of setString.
+func formatValue(v reflect.Value) string {
+	if zeroText(v) {
+		return ""
+	}
+	if s, ok := encodeText(v); ok {
+		return s
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Map:
+		parts := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			parts = append(parts, formatValue(k)+"="+formatValue(v.MapIndex(k)))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
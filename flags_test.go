@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type flagDatabase struct {
+	Host    string        `yaml:"host" usage:"database host"`
+	Port    int           `yaml:"port"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type flagConfig struct {
+	Verbose  bool              `yaml:"verbose"`
+	Hosts    []string          `yaml:"hosts"`
+	Database flagDatabase      `yaml:"database"`
+	Replica  *flagDatabase     `yaml:"replica"`
+	Labels   map[string]string `yaml:"labels"`
+	Secret   string            `yaml:"secret" flag:"-"`
+	Level    string            `yaml:"level" flag:"log-level"`
+	Untagged string
+}
+
+var _ = Describe("Flags", func() {
+	var (
+		fs  *flag.FlagSet
+		dst *flagConfig
+	)
+
+	BeforeEach(func() {
+		fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		dst = &flagConfig{
+			Database: flagDatabase{Host: "db0", Port: 5432},
+		}
+		err := BindFlags(fs, dst)
+		Ω(err).Should(BeNil())
+	})
+
+	It("registers a flag per leaf field", func() {
+		for _, name := range []string{"verbose", "hosts", "database.host", "database.port", "database.timeout", "replica.host", "labels", "log-level", "untagged"} {
+			Ω(fs.Lookup(name)).ShouldNot(BeNil(), name)
+		}
+		Ω(fs.Lookup("secret")).Should(BeNil())
+	})
+
+	It("uses the current values as defaults", func() {
+		Ω(fs.Lookup("database.host").DefValue).Should(Equal("db0"))
+		Ω(fs.Lookup("database.port").DefValue).Should(Equal("5432"))
+
+		var usage bytes.Buffer
+		fs.SetOutput(&usage)
+		fs.PrintDefaults()
+		Ω(usage.String()).Should(ContainSubstring("database host"))
+		Ω(usage.String()).Should(ContainSubstring("(default db0)"))
+	})
+
+	It("overrides fields from the command line", func() {
+		err := fs.Parse([]string{
+			"-verbose",
+			"--database.host", "db1",
+			"--database.timeout=2s",
+			"--hosts=a,b",
+			"--labels=env=prod",
+			"--log-level=debug",
+		})
+		Ω(err).Should(BeNil())
+		Ω(dst.Verbose).Should(BeTrue())
+		Ω(dst.Database).Should(Equal(flagDatabase{Host: "db1", Port: 5432, Timeout: 2 * time.Second}))
+		Ω(dst.Hosts).Should(Equal([]string{"a", "b"}))
+		Ω(dst.Labels).Should(Equal(map[string]string{"env": "prod"}))
+		Ω(dst.Level).Should(Equal("debug"))
+		Ω(dst.Replica).Should(BeNil())
+	})
+
+	It("allocates nested struct pointers on demand", func() {
+		err := fs.Parse([]string{"--replica.port=5433"})
+		Ω(err).Should(BeNil())
+		Ω(dst.Replica).Should(Equal(&flagDatabase{Port: 5433}))
+	})
+
+	It("rejects invalid values", func() {
+		fs.SetOutput(new(bytes.Buffer))
+		err := fs.Parse([]string{"--database.port=many"})
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("checks to make sure dst is a pointer", func() {
+		err := BindFlags(fs, flagConfig{})
+		Ω(err).Should(Equal(ErrNotAPointer))
+	})
+
+	It("rejects fields that collide on a flag name", func() {
+		type collide struct {
+			Host  string
+			Other string `flag:"host"`
+		}
+		err := BindFlags(flag.NewFlagSet("test", flag.ContinueOnError), &collide{})
+		Ω(errors.Is(err, ErrFlagRedefined)).Should(BeTrue())
+	})
+
+	It("rejects binding the same flag set twice", func() {
+		err := BindFlags(fs, &flagConfig{})
+		Ω(errors.Is(err, ErrFlagRedefined)).Should(BeTrue())
+	})
+})
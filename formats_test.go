@@ -0,0 +1,112 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type formatsConfig struct {
+	HasBurrito   bool   `yaml:"has_burrito" json:"has_burrito"`
+	FavoriteHero string `yaml:"favorite_hero" json:"favorite_hero"`
+}
+
+var _ = Describe("Formats", func() {
+	var (
+		cfg    Config
+		tmpDir string
+
+		yamlFormat = &FileFormat{Extension: yamlExtension, Unmarshaller: yaml.Unmarshal}
+		jsonFormat = &FileFormat{Extension: "json", Unmarshaller: json.Unmarshal}
+	)
+
+	dir := func(base string) string {
+		if base == "home" {
+			base = filepath.Join("home", ".config")
+		}
+		return filepath.Join(tmpDir, base, organization, service)
+	}
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+		cfg.FileFormat = nil
+		cfg.Formats = []*FileFormat{yamlFormat, jsonFormat}
+	})
+
+	AfterEach(func() {
+		Ω(os.Unsetenv(correctEnvVar)).Should(BeNil())
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	It("searches every extension", func() {
+		Ω(cfg.searchURIs()).Should(HaveLen(4))
+
+		_, err := cfg.URI()
+		Ω(errors.Is(err, ErrConfigFileNotFound)).Should(BeTrue())
+		Ω(errors.Is(cfg.Load(new(formatsConfig)), ErrConfigFileNotFound)).Should(BeTrue())
+	})
+
+	It("decodes the config file found with its format", func() {
+		writeTestFile(filepath.Join(dir("etc"), "config.json"), `{"has_burrito": true, "favorite_hero": "roadhog"}`)
+		Ω(cfg.Path()).Should(Equal(filepath.Join(dir("etc"), "config.json")))
+
+		td := new(formatsConfig)
+		Ω(cfg.Load(td)).Should(BeNil())
+		Ω(td).Should(Equal(&formatsConfig{HasBurrito: true, FavoriteHero: "roadhog"}))
+	})
+
+	It("prefers a higher level over the order of formats", func() {
+		writeTestFile(filepath.Join(dir("etc"), "config.yaml"), "has_burrito: false\n")
+		writeTestFile(filepath.Join(dir("home"), "config.json"), `{"has_burrito": true, "favorite_hero": "roadhog"}`)
+		Ω(cfg.Path()).Should(Equal(filepath.Join(dir("home"), "config.json")))
+
+		td := new(formatsConfig)
+		Ω(cfg.Load(td)).Should(BeNil())
+		Ω(td).Should(Equal(&formatsConfig{HasBurrito: true, FavoriteHero: "roadhog"}))
+	})
+
+	It("merges layers in different formats", func() {
+		cfg.Layered = true
+		writeTestFile(filepath.Join(dir("etc"), "config.yaml"), "has_burrito: true\nfavorite_hero: mercy\n")
+		writeTestFile(filepath.Join(dir("home"), "config.json"), `{"favorite_hero": "roadhog"}`)
+
+		td := new(formatsConfig)
+		Ω(cfg.Load(td)).Should(BeNil())
+		Ω(td).Should(Equal(&formatsConfig{HasBurrito: true, FavoriteHero: "roadhog"}))
+	})
+
+	It("fails when a level has config files in multiple formats", func() {
+		writeTestFile(filepath.Join(dir("home"), "config.yaml"), "has_burrito: true\n")
+		writeTestFile(filepath.Join(dir("home"), "config.json"), `{"has_burrito": true}`)
+
+		_, err := cfg.URI()
+		Ω(errors.Is(err, ErrAmbiguousFormat)).Should(BeTrue())
+		Ω(err.Error()).Should(ContainSubstring(filepath.Join(dir("home"), "config.json")))
+		Ω(cfg.Path()).Should(BeEmpty())
+
+		err = cfg.Load(new(formatsConfig))
+		Ω(errors.Is(err, ErrAmbiguousFormat)).Should(BeTrue())
+	})
+
+	It("picks the format of the env var URI by extension", func() {
+		path := filepath.Join(tmpDir, "env.json")
+		writeTestFile(path, `{"has_burrito": true, "favorite_hero": "roadhog"}`)
+		Ω(os.Setenv(correctEnvVar, path)).Should(BeNil())
+
+		td := new(formatsConfig)
+		Ω(cfg.Load(td)).Should(BeNil())
+		Ω(td).Should(Equal(&formatsConfig{HasBurrito: true, FavoriteHero: "roadhog"}))
+	})
+
+	It("requires a format", func() {
+		cfg.Formats = nil
+		Ω(cfg.Load(new(formatsConfig))).Should(Equal(ErrNilFileFormat))
+	})
+})
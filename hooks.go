@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	decodeHooksMu sync.RWMutex
+	decodeHooks   = make(map[reflect.Type]func(s string) (reflect.Value, error))
+
+	fileModeType        = reflect.TypeOf(os.FileMode(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func init() {
+	RegisterDecodeHook(time.ParseDuration)
+	RegisterDecodeHook(ParseFileMode)
+	RegisterDecodeHook(url.Parse)
+	RegisterDecodeHook(func(s string) (u url.URL, err error) {
+		parsed, err := url.Parse(s)
+		if err == nil {
+			u = *parsed
+		}
+		return
+	})
+	RegisterDecodeHook(regexp.Compile)
+	RegisterDecodeHook(parseIPNet)
+	RegisterDecodeHook(func(s string) (n net.IPNet, err error) {
+		parsed, err := parseIPNet(s)
+		if err == nil {
+			n = *parsed
+		}
+		return
+	})
+	RegisterDecodeHook(time.LoadLocation)
+	RegisterDecodeHook(func(s string) (l time.Location, err error) {
+		parsed, err := time.LoadLocation(s)
+		if err == nil {
+			l = *parsed
+		}
+		return
+	})
+}
+
+// RegisterDecodeHook makes parse responsible for decoding strings into
+// values of type T, replacing any hook registered for T before. Hooks are
+// consulted by Load for every format, by Tree.Decode, and for environment
+// variables, flags and default tags, so "30s" decodes into a time.Duration
+// the same way everywhere. Types without a hook that implement
+// encoding.TextUnmarshaler are decoded with it, as net.IP and ByteSize are.
+//
+// Hooks are registered for time.Duration, os.FileMode, url.URL,
+// regexp.Regexp, net.IPNet and time.Location, the latter four both as values
+// and pointers. It is safe for concurrent use.
+func RegisterDecodeHook[T any](parse func(s string) (T, error)) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	hook := func(s string) (v reflect.Value, err error) {
+		parsed, err := parse(s)
+		v = reflect.ValueOf(&parsed).Elem()
+		return
+	}
+
+	decodeHooksMu.Lock()
+	defer decodeHooksMu.Unlock()
+	decodeHooks[t] = hook
+}
+
+func lookupDecodeHook(t reflect.Type) (hook func(s string) (reflect.Value, error), ok bool) {
+	decodeHooksMu.RLock()
+	defer decodeHooksMu.RUnlock()
+
+	hook, ok = decodeHooks[t]
+	return
+}
+
+// decodesTextWithin reports whether values of t, or of any type reachable
+// from it through struct fields, elements and pointers, are decoded by
+// decodesText.
+func decodesTextWithin(t reflect.Type) bool {
+	return t != nil && reachesText(t, make(map[reflect.Type]bool))
+}
+
+func reachesText(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	if decodesText(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return reachesText(t.Elem(), seen)
+	case reflect.Map:
+		return reachesText(t.Key(), seen) || reachesText(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+			if reachesText(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodesText reports whether values of t are decoded from strings by a
+// hook or encoding.TextUnmarshaler.
+func decodesText(t reflect.Type) bool {
+	if _, ok := lookupDecodeHook(t); ok {
+		return true
+	}
+	return t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// decodeText sets v from s with the decode hook for its type or its
+// encoding.TextUnmarshaler implementation, reporting whether either applied.
+func decodeText(v reflect.Value, s string) (handled bool, err error) {
+	if hook, ok := lookupDecodeHook(v.Type()); ok {
+		var parsed reflect.Value
+		parsed, err = hook(s)
+		if err == nil {
+			v.Set(parsed)
+		}
+		handled = true
+		return
+	}
+
+	if v.Kind() != reflect.Interface && v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			err = u.UnmarshalText([]byte(s))
+			handled = true
+		}
+	}
+	return
+}
+
+// encodeText returns the string form of v for types decoded by decodeText,
+// from its encoding.TextMarshaler or fmt.Stringer implementation. File modes
+// are formatted in octal.
+func encodeText(v reflect.Value) (s string, ok bool) {
+	if v.Kind() == reflect.Interface || !v.CanInterface() {
+		return
+	}
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return
+	}
+	if !decodesText(v.Type()) {
+		return
+	}
+	if v.Type() == fileModeType {
+		s, ok = fmt.Sprintf("%#o", v.Uint()), true
+		return
+	}
+
+	if !v.CanAddr() {
+		addressable := reflect.New(v.Type()).Elem()
+		addressable.Set(v)
+		v = addressable
+	}
+	for _, i := range []interface{}{v.Interface(), v.Addr().Interface()} {
+		switch m := i.(type) {
+		case encoding.TextMarshaler:
+			text, err := m.MarshalText()
+			if err != nil {
+				return
+			}
+			s, ok = string(text), true
+			return
+		case fmt.Stringer:
+			s, ok = m.String(), true
+			return
+		}
+	}
+	return
+}
+
+// zeroText reports whether v is the zero value of a struct decoded by
+// decodeText. Zero structs such as net.IPNet{} have no string form and are
+// left out instead.
+func zeroText(v reflect.Value) bool {
+	return v.Kind() == reflect.Struct && v.IsZero() && decodesText(v.Type())
+}
+
+// ParseFileMode parses an octal file mode such as "0644", "644" or "0o644".
+func ParseFileMode(s string) (mode os.FileMode, err error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+	m, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil {
+		err = fmt.Errorf("invalid file mode %q", s)
+		return
+	}
+	mode = os.FileMode(m)
+	return
+}
+
+func parseIPNet(s string) (n *net.IPNet, err error) {
+	_, n, err = net.ParseCIDR(s)
+	return
+}
@@ -0,0 +1,309 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/bsdlp/config/fileformat/ini"
+	"github.com/hashicorp/hcl"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type richConfig struct {
+	Timeout  time.Duration       `config:"timeout"`
+	MaxBody  ByteSize            `config:"max_body"`
+	Listen   net.IP              `config:"listen"`
+	Allow    net.IPNet           `config:"allow"`
+	Deny     []*net.IPNet        `config:"deny"`
+	Upstream *url.URL            `config:"upstream"`
+	Pattern  *regexp.Regexp      `config:"pattern"`
+	Mode     os.FileMode         `config:"mode"`
+	Zone     *time.Location      `config:"zone"`
+	Started  time.Time           `config:"started"`
+	Limits   map[string]ByteSize `config:"limits"`
+}
+
+// hookedConfig names its fields with the tags of every format.
+type hookedConfig struct {
+	Timeout  time.Duration `yaml:"timeout" json:"timeout" toml:"timeout" hcl:"timeout" ini:"timeout"`
+	Mode     os.FileMode   `yaml:"mode" json:"mode" toml:"mode" hcl:"mode" ini:"mode"`
+	Upstream *url.URL      `yaml:"upstream" json:"upstream" toml:"upstream" hcl:"upstream" ini:"upstream"`
+	MaxBody  ByteSize      `yaml:"max_body" json:"max_body" toml:"max_body" hcl:"max_body" ini:"max_body"`
+	Name     string
+}
+
+// textConfig only has types decoded with encoding.TextUnmarshaler.
+type textConfig struct {
+	MaxBody ByteSize `hcl:"max_body" ini:"max_body"`
+	Listen  net.IP   `hcl:"listen" ini:"listen"`
+}
+
+type hookServer struct {
+	Name string `toml:"name"`
+}
+
+// upperString decodes yaml strings with a prefix, to tell whether
+// UnmarshalYAML was called.
+type upperString string
+
+func (u *upperString) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	var s string
+	err = unmarshal(&s)
+	*u = upperString("UP:" + s)
+	return
+}
+
+var _ = Describe("Decode hooks", func() {
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+		cfg.ConfigTags = true
+	})
+
+	AfterEach(func() {
+		Ω(os.Unsetenv("TESTORG_TESTSERVICE_UPSTREAM")).Should(BeNil())
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	check := func(dst *richConfig) {
+		Ω(dst.Timeout).Should(Equal(30 * time.Second))
+		Ω(dst.MaxBody).Should(Equal(10 * MiB))
+		Ω(dst.Listen.String()).Should(Equal("10.0.0.1"))
+		Ω(dst.Allow.String()).Should(Equal("10.0.0.0/8"))
+		Ω(dst.Deny).Should(HaveLen(2))
+		Ω(dst.Deny[1].String()).Should(Equal("192.168.0.0/16"))
+		Ω(dst.Upstream.Host).Should(Equal("example.com"))
+		Ω(dst.Pattern.MatchString("abc123")).Should(BeTrue())
+		Ω(dst.Mode).Should(Equal(os.FileMode(0640)))
+		Ω(dst.Zone.String()).Should(Equal("UTC"))
+		Ω(dst.Started).Should(Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+		Ω(dst.Limits).Should(Equal(map[string]ByteSize{"upload": 1500 * KB}))
+	}
+
+	formats := []struct {
+		format *FileFormat
+		data   string
+	}{
+		{&FileFormat{Extension: "yaml", Unmarshaller: yaml.Unmarshal},
+			"timeout: 30s\nmax_body: 10MiB\nlisten: 10.0.0.1\nallow: 10.0.0.0/8\n" +
+				"deny: [172.16.0.0/12, 192.168.0.0/16]\nupstream: https://example.com/api\n" +
+				"pattern: ^[a-z]+[0-9]+$\nmode: \"0640\"\nzone: UTC\nstarted: \"2020-01-02T03:04:05Z\"\n" +
+				"limits:\n  upload: 1.5MB\n"},
+		{&FileFormat{Extension: "json", Unmarshaller: json.Unmarshal},
+			`{"timeout": "30s", "max_body": "10MiB", "listen": "10.0.0.1", "allow": "10.0.0.0/8",
+			"deny": ["172.16.0.0/12", "192.168.0.0/16"], "upstream": "https://example.com/api",
+			"pattern": "^[a-z]+[0-9]+$", "mode": 416, "zone": "UTC", "started": "2020-01-02T03:04:05Z",
+			"limits": {"upload": "1.5MB"}}`},
+		{&FileFormat{Extension: "toml", Unmarshaller: toml.Unmarshal},
+			"timeout = \"30s\"\nmax_body = \"10MiB\"\nlisten = \"10.0.0.1\"\nallow = \"10.0.0.0/8\"\n" +
+				"deny = [\"172.16.0.0/12\", \"192.168.0.0/16\"]\nupstream = \"https://example.com/api\"\n" +
+				"pattern = '^[a-z]+[0-9]+$'\nmode = \"0o640\"\nzone = \"UTC\"\nstarted = \"2020-01-02T03:04:05Z\"\n" +
+				"[limits]\nupload = \"1.5MB\"\n"},
+	}
+	for _, f := range formats {
+		f := f
+		It("decodes "+f.format.Extension+" strings into rich types", func() {
+			cfg.FileFormat = f.format
+			writeTestFile(cfg.systemURI().Path, f.data)
+
+			dst := new(richConfig)
+			Ω(cfg.Load(dst)).Should(BeNil())
+			check(dst)
+		})
+	}
+
+	hooked := []struct {
+		format *FileFormat
+		data   string
+	}{
+		{&FileFormat{Extension: "yaml", Unmarshaller: yaml.Unmarshal},
+			"timeout: 30s\nmode: \"0640\"\nupstream: https://example.com/api\nmax_body: 10MiB\nname: canary\n"},
+		{&FileFormat{Extension: "json", Unmarshaller: json.Unmarshal},
+			`{"timeout": "30s", "mode": "0640", "upstream": "https://example.com/api", "max_body": "10MiB", "name": "canary"}`},
+		{&FileFormat{Extension: "toml", Unmarshaller: toml.Unmarshal},
+			"timeout = \"30s\"\nmode = \"0640\"\nupstream = \"https://example.com/api\"\nmax_body = \"10MiB\"\nname = \"canary\"\n"},
+		{&FileFormat{Extension: "hcl", Unmarshaller: hcl.Unmarshal},
+			"timeout = \"30s\"\nmode = \"0640\"\nupstream = \"https://example.com/api\"\nmax_body = \"10MiB\"\nname = \"canary\"\n"},
+		{&FileFormat{Extension: "ini", Unmarshaller: ini.Unmarshal},
+			"timeout = 30s\nmode = 0640\nupstream = https://example.com/api\nmax_body = 10MiB\nname = canary\n"},
+	}
+	for _, f := range hooked {
+		f := f
+		It("decodes "+f.format.Extension+" without ConfigTags", func() {
+			cfg.ConfigTags = false
+			cfg.FileFormat = f.format
+			writeTestFile(cfg.systemURI().Path, f.data)
+
+			dst := new(hookedConfig)
+			Ω(cfg.Load(dst)).Should(BeNil())
+			Ω(dst.Timeout).Should(Equal(30 * time.Second))
+			Ω(dst.Mode).Should(Equal(os.FileMode(0640)))
+			Ω(dst.Upstream.Host).Should(Equal("example.com"))
+			Ω(dst.MaxBody).Should(Equal(10 * MiB))
+			Ω(dst.Name).Should(Equal("canary"))
+		})
+	}
+
+	for _, f := range []*FileFormat{
+		{Extension: "hcl", Unmarshaller: hcl.Unmarshal},
+		{Extension: "ini", Unmarshaller: ini.Unmarshal},
+	} {
+		f := f
+		It("decodes text unmarshalers from "+f.Extension, func() {
+			cfg.ConfigTags = false
+			cfg.FileFormat = f
+			writeTestFile(cfg.systemURI().Path, "max_body = \"10MiB\"\nlisten = \"10.0.0.1\"\n")
+
+			dst := new(textConfig)
+			Ω(cfg.Load(dst)).Should(BeNil())
+			Ω(dst.MaxBody).Should(Equal(10 * MiB))
+			Ω(dst.Listen.String()).Should(Equal("10.0.0.1"))
+		})
+	}
+
+	It("keeps decoding arrays of tables with the format", func() {
+		cfg.ConfigTags = false
+		cfg.FileFormat = &FileFormat{Extension: "toml", Unmarshaller: toml.Unmarshal}
+		writeTestFile(cfg.systemURI().Path, "timeout = \"5s\"\n\n[[servers]]\nname = \"a\"\n\n[[servers]]\nname = \"b\"\n")
+
+		dst := new(struct {
+			Servers []hookServer  `toml:"servers"`
+			Timeout time.Duration `toml:"timeout"`
+		})
+		Ω(cfg.Load(dst)).Should(BeNil())
+		Ω(dst.Servers).Should(Equal([]hookServer{{Name: "a"}, {Name: "b"}}))
+		Ω(dst.Timeout).Should(Equal(5 * time.Second))
+	})
+
+	It("keeps the unmarshal methods of the format", func() {
+		cfg.ConfigTags = false
+		writeTestFile(cfg.systemURI().Path, "name: x\ntimeout: 5s\n")
+
+		dst := new(struct {
+			Name    upperString   `yaml:"name"`
+			Timeout time.Duration `yaml:"timeout"`
+		})
+		Ω(cfg.Load(dst)).Should(BeNil())
+		Ω(dst.Name).Should(Equal(upperString("UP:x")))
+		Ω(dst.Timeout).Should(Equal(5 * time.Second))
+	})
+
+	It("applies to environment variables, flags and trees", func() {
+		cfg.FileFormat = &FileFormat{Extension: "yaml", Unmarshaller: yaml.Unmarshal}
+		cfg.EnvOverrides = true
+		writeTestFile(cfg.systemURI().Path, "timeout: 1m\n")
+		Ω(os.Setenv("TESTORG_TESTSERVICE_UPSTREAM", "http://env.example.com")).Should(BeNil())
+
+		dst := new(richConfig)
+		Ω(cfg.Load(dst)).Should(BeNil())
+		Ω(dst.Upstream.Host).Should(Equal("env.example.com"))
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		BindFlags(fs, dst)
+		Ω(fs.Lookup("upstream").DefValue).Should(Equal("http://env.example.com"))
+		Ω(fs.Lookup("timeout").DefValue).Should(Equal("1m0s"))
+		Ω(fs.Parse([]string{"-allow", "10.0.0.0/8", "-mode", "0600", "-max_body", "2G"})).Should(BeNil())
+		Ω(dst.Allow.String()).Should(Equal("10.0.0.0/8"))
+		Ω(dst.Mode).Should(Equal(os.FileMode(0600)))
+		Ω(dst.MaxBody).Should(Equal(2 * GiB))
+
+		t, err := cfg.LoadTree()
+		Ω(err).Should(BeNil())
+		var zone *time.Location
+		Ω(Tree{"zone": "UTC"}.Decode("zone", &zone)).Should(BeNil())
+		Ω(zone).Should(Equal(time.UTC))
+		Ω(t.GetDuration("timeout")).Should(Equal(time.Minute))
+	})
+
+	It("encodes rich types as the strings they decode from", func() {
+		src := &richConfig{MaxBody: 3 * KiB, Mode: 0644, Timeout: time.Second}
+		src.Upstream, _ = url.Parse("https://example.com")
+		src.Listen = net.ParseIP("::1")
+
+		tree, err := EncodeTree(src, ConfigTag)
+		Ω(err).Should(BeNil())
+		Ω(tree["max_body"]).Should(Equal("3KiB"))
+		Ω(tree["mode"]).Should(Equal("0644"))
+		Ω(tree["timeout"]).Should(Equal("1s"))
+		Ω(tree["upstream"]).Should(Equal("https://example.com"))
+		Ω(tree["listen"]).Should(Equal("::1"))
+		Ω(tree["pattern"]).Should(BeNil())
+
+		dst := new(richConfig)
+		d := &decoder{tags: []string{ConfigTag}}
+		Ω(d.decode(nil, tree, reflect.ValueOf(dst))).Should(BeNil())
+		Ω(dst.MaxBody).Should(Equal(src.MaxBody))
+		Ω(dst.Upstream).Should(Equal(src.Upstream))
+	})
+
+	It("uses registered hooks", func() {
+		type celsius float64
+		RegisterDecodeHook(func(s string) (c celsius, err error) {
+			var f float64
+			_, err = fmt.Sscanf(s, "%fC", &f)
+			c = celsius(f)
+			return
+		})
+
+		var dst struct {
+			Max celsius `config:"max"`
+		}
+		Ω(Tree{"max": "21.5C"}.Decode("", &dst)).Should(BeNil())
+		Ω(dst.Max).Should(Equal(celsius(21.5)))
+	})
+
+	It("reports invalid values", func() {
+		var dst richConfig
+		err := Tree{"pattern": "("}.Decode("", &dst)
+		Ω(err).ShouldNot(BeNil())
+		Ω(err.Error()).Should(ContainSubstring(`"pattern"`))
+	})
+})
+
+var _ = Describe("ByteSize", func() {
+	It("parses sizes with units", func() {
+		for s, expected := range map[string]ByteSize{
+			"0":       0,
+			"512":     512,
+			"512B":    512,
+			"1kb":     KB,
+			"10MiB":   10 * MiB,
+			"1.5 GB":  1500 * MB,
+			"2G":      2 * GiB,
+			"0.5KiB":  512,
+			" 3 TiB ": 3 * TiB,
+		} {
+			size, err := ParseByteSize(s)
+			Ω(err).Should(BeNil(), s)
+			Ω(size).Should(Equal(expected), s)
+		}
+
+		for _, s := range []string{"", "MB", "10XB", "-1", "1.2.3K", "20000000PB"} {
+			_, err := ParseByteSize(s)
+			Ω(err).ShouldNot(BeNil(), s)
+		}
+	})
+
+	It("formats sizes in the largest exact binary unit", func() {
+		Ω((10 * MiB).String()).Should(Equal("10MiB"))
+		Ω((1536 * KiB).String()).Should(Equal("1536KiB"))
+		Ω(KB.String()).Should(Equal("1000B"))
+		Ω(ByteSize(0).String()).Should(Equal("0B"))
+	})
+})
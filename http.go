@@ -0,0 +1,227 @@
+package config
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTP source defaults.
+const (
+	// DefaultHTTPTimeout is the timeout of the client used when no
+	// http.Client is configured
+	DefaultHTTPTimeout = 30 * time.Second
+
+	// DefaultMaxBodySize is the largest config accepted over http when no
+	// maximum size is configured
+	DefaultMaxBodySize int64 = 10 << 20
+
+	// DefaultMaxRedirects is the number of redirects followed when no limit
+	// is configured
+	DefaultMaxRedirects = 10
+)
+
+var (
+	// ErrBodyTooLarge is returned when an http response body exceeds the
+	// configured maximum size
+	ErrBodyTooLarge = errors.New("config: http response body too large")
+
+	// ErrTooManyRedirects is returned when an http request is redirected more
+	// often than allowed
+	ErrTooManyRedirects = errors.New("config: too many http redirects")
+)
+
+// HTTPStatusError is returned when an http config URI responds with a
+// non-2xx status.
+type HTTPStatusError struct {
+	URI        string
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("config: fetching %s: unexpected http status %s", e.URI, e.Status)
+}
+
+// HTTPSource is the Source registered for the http and https schemes. Zero
+// fields take their value from the Config being loaded, then from the
+// package defaults.
+type HTTPSource struct {
+	// Client used for requests
+	Client *http.Client
+
+	// Header is added to every request
+	Header http.Header
+
+	// MaxBodySize limits the size of fetched configs
+	MaxBodySize int64
+
+	// MaxRedirects limits the number of redirects followed, a negative value
+	// disables redirects
+	MaxRedirects int
+}
+
+// Fetch implements Source.
+func (s *HTTPSource) Fetch(ctx context.Context, uri *url.URL) (data []byte, err error) {
+	data, _, err = s.FetchTyped(ctx, uri)
+	return
+}
+
+// FetchTyped implements TypedSource, reporting the Content-Type of the
+// response.
+func (s *HTTPSource) FetchTyped(ctx context.Context, uri *url.URL) (data []byte, contentType string, err error) {
+	f := &httpFetcher{
+		client:       s.Client,
+		header:       s.Header,
+		maxBodySize:  s.MaxBodySize,
+		maxRedirects: s.MaxRedirects,
+	}
+	data, contentType, err = readHTTP(ctx, f, uri.String())
+	return
+}
+
+func (s *HTTPSource) configure(c Config) Source {
+	configured := *s
+	if configured.Client == nil {
+		configured.Client = c.HTTPClient
+	}
+	if configured.Header == nil {
+		configured.Header = c.HTTPHeader
+	}
+	if configured.MaxBodySize == 0 {
+		configured.MaxBodySize = c.MaxBodySize
+	}
+	if configured.MaxRedirects == 0 {
+		configured.MaxRedirects = c.MaxRedirects
+	}
+	return &configured
+}
+
+// httpFetcher fetches http and https URIs.
+type httpFetcher struct {
+	// client used for requests, nil for a default client
+	client *http.Client
+
+	// added to every request
+	header http.Header
+
+	// response bodies larger than this are rejected, 0 for
+	// DefaultMaxBodySize
+	maxBodySize int64
+
+	// number of redirects followed, 0 for DefaultMaxRedirects and negative to
+	// disable redirects
+	maxRedirects int
+}
+
+func (f *httpFetcher) httpClient() *http.Client {
+	var client http.Client
+	if f != nil && f.client != nil {
+		client = *f.client
+	} else {
+		client.Timeout = DefaultHTTPTimeout
+	}
+
+	maxRedirects := DefaultMaxRedirects
+	if f != nil && f.maxRedirects != 0 {
+		maxRedirects = f.maxRedirects
+	}
+	if client.CheckRedirect == nil || (f != nil && f.maxRedirects != 0) {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return ErrTooManyRedirects
+			}
+			return nil
+		}
+	}
+	return &client
+}
+
+// do sends req and returns the body of 2xx responses along with the
+// response, whose body is already closed. 304 responses are returned without
+// data or error, other statuses as *HTTPStatusError.
+func (f *httpFetcher) do(req *http.Request) (resp *http.Response, data []byte, err error) {
+	if f != nil {
+		for k, vs := range f.header {
+			for _, v := range vs {
+				req.Header.Add(k, v)
+			}
+		}
+	}
+	// requesting gzip explicitly disables the transport's transparent
+	// decompression, so the size limit applies to the decompressed body
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err = f.httpClient().Do(req)
+	if err != nil {
+		return
+	}
+	defer func() {
+		closeErr := resp.Body.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = &HTTPStatusError{
+			URI:        req.URL.Redacted(),
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		}
+		return
+	}
+
+	var body io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" && !resp.Uncompressed {
+		var gz *gzip.Reader
+		gz, err = gzip.NewReader(resp.Body)
+		if err != nil {
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	maxBodySize := DefaultMaxBodySize
+	if f != nil && f.maxBodySize > 0 {
+		maxBodySize = f.maxBodySize
+	}
+	data, err = ioutil.ReadAll(io.LimitReader(body, maxBodySize+1))
+	if err != nil {
+		return
+	}
+	if int64(len(data)) > maxBodySize {
+		data = nil
+		err = ErrBodyTooLarge
+	}
+	return
+}
+
+// readHTTP reads the body and Content-Type of a GET request to uri,
+// streaming bodies of unknown length up to the fetcher's size limit.
+func readHTTP(ctx context.Context, f *httpFetcher, uri string) (data []byte, contentType string, err error) {
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return
+	}
+	req = req.WithContext(ctx)
+
+	resp, data, err := f.do(req)
+	if err != nil {
+		return
+	}
+	contentType = resp.Header.Get("Content-Type")
+	return
+}
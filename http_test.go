@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTP", func() {
+	var (
+		ts  *httptest.Server
+		mux *http.ServeMux
+		f   *httpFetcher
+	)
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		ts = httptest.NewServer(mux)
+		f = &httpFetcher{}
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	It("streams bodies of unknown length", func() {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			for _, line := range strings.SplitAfter(testConfigData, "\n") {
+				_, err := w.Write([]byte(line))
+				Ω(err).Should(BeNil())
+				w.(http.Flusher).Flush()
+			}
+		})
+
+		data, _, err := readHTTP(context.Background(), f, ts.URL)
+		Ω(err).Should(BeNil())
+		Ω(data).Should(Equal([]byte(testConfigData)))
+	})
+
+	It("returns typed errors for non-2xx responses", func() {
+		mux.HandleFunc("/", http.NotFound)
+
+		_, _, err := readHTTP(context.Background(), f, ts.URL+"/missing.yaml")
+		var statusErr *HTTPStatusError
+		Ω(errors.As(err, &statusErr)).Should(BeTrue())
+		Ω(statusErr.StatusCode).Should(Equal(http.StatusNotFound))
+		Ω(statusErr.URI).Should(Equal(ts.URL + "/missing.yaml"))
+	})
+
+	It("keeps passwords out of errors", func() {
+		mux.HandleFunc("/missing.yaml", http.NotFound)
+		mux.HandleFunc("/invalid.yaml", func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte("location: [\n"))
+			Ω(err).Should(BeNil())
+		})
+		withPassword := strings.Replace(ts.URL, "://", "://admin:hunter2@", 1)
+
+		_, _, err := readHTTP(context.Background(), f, withPassword+"/missing.yaml")
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).ShouldNot(ContainSubstring("hunter2"))
+
+		tmpDir := newTestDir()
+		defer os.RemoveAll(tmpDir)
+		cfg := newTestConfig(tmpDir)
+		Ω(os.Setenv(cfg.EnvVar(), withPassword+"/invalid.yaml")).Should(BeNil())
+		defer os.Unsetenv(cfg.EnvVar())
+		for _, layered := range []bool{false, true} {
+			cfg.Layered = layered
+			err = cfg.Load(new(watchConfig))
+			var parseErr *ParseError
+			Ω(errors.As(err, &parseErr)).Should(BeTrue())
+			Ω(err.Error()).ShouldNot(ContainSubstring("hunter2"))
+		}
+	})
+
+	It("limits the body size", func() {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write(bytes.Repeat([]byte("a"), 100))
+			Ω(err).Should(BeNil())
+		})
+
+		f.maxBodySize = 99
+		_, _, err := readHTTP(context.Background(), f, ts.URL)
+		Ω(err).Should(Equal(ErrBodyTooLarge))
+
+		f.maxBodySize = 100
+		data, _, err := readHTTP(context.Background(), f, ts.URL)
+		Ω(err).Should(BeNil())
+		Ω(data).Should(HaveLen(100))
+	})
+
+	It("decompresses gzip bodies", func() {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			Ω(r.Header.Get("Accept-Encoding")).Should(Equal("gzip"))
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			_, err := gz.Write([]byte(testConfigData))
+			Ω(err).Should(BeNil())
+			Ω(gz.Close()).Should(BeNil())
+		})
+
+		data, _, err := readHTTP(context.Background(), f, ts.URL)
+		Ω(err).Should(BeNil())
+		Ω(data).Should(Equal([]byte(testConfigData)))
+	})
+
+	It("limits redirects", func() {
+		mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		})
+
+		f.maxRedirects = 2
+		_, _, err := readHTTP(context.Background(), f, ts.URL+"/loop")
+		Ω(errors.Is(err, ErrTooManyRedirects)).Should(BeTrue())
+	})
+
+	It("uses the configured client and headers", func() {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			Ω(r.Header.Get("Authorization")).Should(Equal("Bearer token"))
+			_, err := w.Write([]byte(testConfigData))
+			Ω(err).Should(BeNil())
+		})
+
+		var used bool
+		cfg := Config{
+			HTTPClient: &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				used = true
+				return http.DefaultTransport.RoundTrip(r)
+			})},
+			HTTPHeader: http.Header{"Authorization": {"Bearer token"}},
+		}
+		data, _, err := cfg.fetch(context.Background(), ts.URL)
+		Ω(err).Should(BeNil())
+		Ω(data).Should(Equal([]byte(testConfigData)))
+		Ω(used).Should(BeTrue())
+	})
+})
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package config
+
+// lockFile is a no-op on platforms without flock(2).
+func lockFile(path string) (unlock func() error, err error) {
+	unlock = func() error { return nil }
+	return
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock(2) on the file at path, creating it if
+// needed, and returns the function releasing it.
+func lockFile(path string) (unlock func() error, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, SaveFileMode)
+	if err != nil {
+		return
+	}
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		_ = f.Close()
+		return
+	}
+
+	unlock = func() error {
+		// closing the file releases the lock
+		return f.Close()
+	}
+	return
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Poller defaults.
+const (
+	DefaultPollerInterval   = 30 * time.Second
+	DefaultPollerJitter     = 0.1
+	DefaultPollerMaxBackoff = 5 * time.Minute
+)
+
+// Poller periodically fetches an http or https config URI and notifies its
+// subscribers when the content changes. Requests are conditional on the
+// ETag and Last-Modified headers of the previous response, so unchanged
+// configs cost the server a 304.
+type Poller struct {
+	// URI of the config to poll
+	URI string
+
+	// Interval between polls. Defaults to DefaultPollerInterval.
+	Interval time.Duration
+
+	// Jitter is the fraction of the interval randomly added to or subtracted
+	// from every delay, so that a fleet of services doesn't poll in lockstep.
+	// Defaults to DefaultPollerJitter, a negative value disables jitter.
+	Jitter float64
+
+	// MaxBackoff caps the exponential backoff applied after failed polls.
+	// Defaults to DefaultPollerMaxBackoff.
+	MaxBackoff time.Duration
+
+	// Client used for requests. Defaults to a client with a
+	// DefaultHTTPTimeout timeout.
+	Client *http.Client
+
+	// Header is added to every request.
+	Header http.Header
+
+	// MaxBodySize limits the size of the fetched config. Defaults to
+	// DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// OnError is called with the error of every failed poll, if set.
+	OnError func(error)
+
+	mu           sync.Mutex
+	subscribers  map[int]func([]byte)
+	nextID       int
+	etag         string
+	lastModified string
+	sum          []byte
+}
+
+// NewPoller returns a Poller for uri polling every interval.
+func NewPoller(uri string, interval time.Duration) *Poller {
+	return &Poller{URI: uri, Interval: interval}
+}
+
+// Subscribe registers fn to be called with the new content whenever it
+// changes, including the first successful poll. The returned function
+// removes the subscription.
+func (p *Poller) Subscribe(fn func(data []byte)) (unsubscribe func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.subscribers == nil {
+		p.subscribers = make(map[int]func([]byte))
+	}
+	id := p.nextID
+	p.nextID++
+	p.subscribers[id] = fn
+
+	unsubscribe = func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.subscribers, id)
+	}
+	return
+}
+
+// Run polls until ctx is done and returns ctx.Err(). The first poll happens
+// immediately.
+func (p *Poller) Run(ctx context.Context) (err error) {
+	var failures uint
+	for {
+		data, changed, pollErr := p.poll(ctx)
+		switch {
+		case pollErr != nil:
+			if ctx.Err() == nil && p.OnError != nil {
+				p.OnError(pollErr)
+			}
+			failures++
+		case changed:
+			failures = 0
+			p.notify(data)
+		default:
+			failures = 0
+		}
+
+		timer := time.NewTimer(p.delay(failures))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the jittered wait before the next poll after failures
+// consecutive failed polls.
+func (p *Poller) delay(failures uint) time.Duration {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultPollerInterval
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultPollerMaxBackoff
+	}
+	jitter := p.Jitter
+	if jitter == 0 {
+		jitter = DefaultPollerJitter
+	}
+
+	d := interval
+	for i := uint(0); i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if failures > 0 && d > maxBackoff {
+		d = maxBackoff
+	}
+
+	if jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * jitter * float64(d))
+	}
+	return d
+}
+
+func (p *Poller) notify(data []byte) {
+	p.mu.Lock()
+	subscribers := make([]func([]byte), 0, len(p.subscribers))
+	for _, fn := range p.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	p.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(data)
+	}
+}
+
+// poll issues a conditional GET and reports whether the content changed
+// since the last successful poll.
+func (p *Poller) poll(ctx context.Context) (data []byte, changed bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, p.URI, nil)
+	if err != nil {
+		return
+	}
+	req = req.WithContext(ctx)
+
+	p.mu.Lock()
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+	if p.lastModified != "" {
+		req.Header.Set("If-Modified-Since", p.lastModified)
+	}
+	p.mu.Unlock()
+
+	f := &httpFetcher{client: p.Client, header: p.Header, maxBodySize: p.MaxBodySize}
+	resp, data, err := f.do(req)
+	if err != nil || resp.StatusCode == http.StatusNotModified {
+		return
+	}
+
+	sum := sha256.Sum256(data)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.etag = resp.Header.Get("ETag")
+	p.lastModified = resp.Header.Get("Last-Modified")
+	changed = p.sum == nil || !bytes.Equal(p.sum, sum[:])
+	p.sum = sum[:]
+	return
+}
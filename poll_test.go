@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Poller", func() {
+	var (
+		ts          *httptest.Server
+		mu          sync.Mutex
+		body        string
+		status      int
+		notModified int
+		p           *Poller
+	)
+
+	BeforeEach(func() {
+		body = testConfigData
+		status = http.StatusOK
+		notModified = 0
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			etag := fmt.Sprintf(`"%x"`, body)
+			if r.Header.Get("If-None-Match") == etag {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.WriteHeader(status)
+			_, err := w.Write([]byte(body))
+			Ω(err).Should(BeNil())
+		}))
+		p = NewPoller(ts.URL, 10*time.Millisecond)
+		p.Jitter = -1
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	It("issues conditional requests", func() {
+		data, changed, err := p.poll(context.Background())
+		Ω(err).Should(BeNil())
+		Ω(changed).Should(BeTrue())
+		Ω(data).Should(Equal([]byte(testConfigData)))
+
+		_, changed, err = p.poll(context.Background())
+		Ω(err).Should(BeNil())
+		Ω(changed).Should(BeFalse())
+		Ω(notModified).Should(Equal(1))
+	})
+
+	It("notifies subscribers only when the content changes", func() {
+		received := make(chan []byte, 10)
+		p.Subscribe(func(data []byte) { received <- data })
+		unsubscribed := make(chan []byte, 10)
+		p.Subscribe(func(data []byte) { unsubscribed <- data })()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- p.Run(ctx) }()
+
+		Eventually(received).Should(Receive(Equal([]byte(testConfigData))))
+		Eventually(func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return notModified
+		}).Should(BeNumerically(">=", 2))
+		Consistently(received, 50*time.Millisecond).ShouldNot(Receive())
+
+		mu.Lock()
+		body = "location: changed"
+		mu.Unlock()
+		Eventually(received).Should(Receive(Equal([]byte("location: changed"))))
+		Ω(unsubscribed).ShouldNot(Receive())
+
+		cancel()
+		Eventually(done).Should(Receive(Equal(context.Canceled)))
+	})
+
+	It("reports error statuses", func() {
+		status = http.StatusNotFound
+		errs := make(chan error, 10)
+		p.OnError = func(err error) { errs <- err }
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go p.Run(ctx)
+
+		var err error
+		Eventually(errs).Should(Receive(&err))
+		Ω(err.Error()).Should(ContainSubstring("404"))
+	})
+
+	It("backs off exponentially on errors", func() {
+		p.Interval = time.Second
+		p.MaxBackoff = 5 * time.Second
+		Ω(p.delay(0)).Should(Equal(time.Second))
+		Ω(p.delay(1)).Should(Equal(2 * time.Second))
+		Ω(p.delay(2)).Should(Equal(4 * time.Second))
+		Ω(p.delay(10)).Should(Equal(5 * time.Second))
+	})
+
+	It("jitters delays", func() {
+		p.Interval = time.Second
+		p.Jitter = 0.5
+		for i := 0; i < 20; i++ {
+			Ω(p.delay(0)).Should(BeNumerically("~", time.Second, 500*time.Millisecond))
+		}
+	})
+})
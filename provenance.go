@@ -0,0 +1,160 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Position is the location of a key in config data. Lines and columns start
+// at 1, zero values mean the position is unknown.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Locator defines the function signature for functions returning the
+// position of every key in config data by its dotted path, i.e.
+// "database.port"
+type Locator func(data []byte) (map[string]Position, error)
+
+// Origin is where a config value was loaded from.
+type Origin struct {
+	// URI of the config, "env:NAME" for environment variables and "default"
+	// for DefaultData
+	URI string
+
+	// Position of the key in the config, if its format has a Locator
+	Position
+}
+
+func (o Origin) String() string {
+	if o.Line == 0 {
+		return o.URI
+	}
+	if o.Column == 0 {
+		return fmt.Sprintf("%s:%d", o.URI, o.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", o.URI, o.Line, o.Column)
+}
+
+// Provenance maps dotted key paths to the origin of their values. The empty
+// path holds the origin of values without a more specific entry.
+type Provenance map[string]Origin
+
+// Lookup returns the origin of path or its closest recorded ancestor.
+func (p Provenance) Lookup(path string) Origin {
+	for {
+		if o, ok := p[path]; ok {
+			return o
+		}
+		if path == "" {
+			return Origin{}
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			path = ""
+		} else {
+			path = path[:i]
+		}
+	}
+}
+
+// record sets uri as the origin of every value in tree, replacing the
+// origins of values it overrides. positions, which may be nil, are the
+// positions of keys in the config at uri.
+func (p Provenance) record(path []string, tree interface{}, uri string, positions map[string]Position) {
+	m, ok := tree.(map[string]interface{})
+	if !ok {
+		key := pathString(path)
+		p[key] = Origin{URI: uri, Position: positions[key]}
+		return
+	}
+	for k, v := range m {
+		p.record(append(path[:len(path):len(path)], k), v, uri, positions)
+	}
+}
+
+// LoadProvenance loads the config into dst like LoadContext and returns the
+// origin of every value read from a config or the environment, with
+// positions for formats that have a Locator.
+func (c Config) LoadProvenance(ctx context.Context, dst interface{}) (p Provenance, err error) {
+	c.provenance = make(Provenance)
+	err = c.LoadContext(ctx, dst)
+	if err != nil {
+		return
+	}
+
+	p = c.provenance
+	delete(p, "")
+	return
+}
+
+// locate returns the positions of the keys in data if provenance is being
+// recorded and format has a Locator.
+func (c Config) locate(format *FileFormat, data []byte) (positions map[string]Position) {
+	if c.provenance == nil || format == nil || format.Locator == nil {
+		return
+	}
+	positions, _ = format.Locator(data)
+	return
+}
+
+// Fprint writes the effective config v, a struct or map as passed to Load,
+// to w with one dotted key and its JSON encoded value per line, annotated
+// with the origin of the value. Keys are named after the first config, yaml,
+// json, toml, hcl or ini tag of every field.
+func (p Provenance) Fprint(w io.Writer, v interface{}) (err error) {
+	tree, err := encodeTree(v, keyTags...)
+	if err != nil {
+		return
+	}
+
+	var lines [][2]string
+	var walk func(path []string, v interface{}) error
+	walk = func(path []string, v interface{}) error {
+		if m, ok := v.(map[string]interface{}); ok && (len(m) > 0 || len(path) == 0) {
+			for k, elem := range m {
+				if err := walk(append(path[:len(path):len(path)], k), elem); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, [2]string{pathString(path), string(value)})
+		return nil
+	}
+	err = walk(nil, tree)
+	if err != nil {
+		return
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][0] < lines[j][0] })
+
+	// align the origins of all lines
+	width := 0
+	for _, line := range lines {
+		if n := len(line[0]) + len(line[1]); n > width {
+			width = n
+		}
+	}
+	for _, line := range lines {
+		origin := p.Lookup(line[0])
+		if origin.URI == "" {
+			_, err = fmt.Fprintf(w, "%s = %s\n", line[0], line[1])
+		} else {
+			pad := strings.Repeat(" ", width-len(line[0])-len(line[1]))
+			_, err = fmt.Fprintf(w, "%s = %s%s  # %s\n", line[0], line[1], pad, origin)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// locateTopLevel is a Locator for the top level keys of yaml documents.
+func locateTopLevel(data []byte) (map[string]Position, error) {
+	positions := make(map[string]Position)
+	for i, line := range strings.Split(string(data), "\n") {
+		if j := strings.Index(line, ":"); j > 0 && line[0] != ' ' {
+			positions[line[:j]] = Position{Line: i + 1, Column: 1}
+		}
+	}
+	return positions, nil
+}
+
+var _ = Describe("Provenance", func() {
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+		cfg.FileFormat = &FileFormat{
+			Extension:    yamlExtension,
+			Unmarshaller: yaml.Unmarshal,
+			Locator:      locateTopLevel,
+		}
+	})
+
+	AfterEach(func() {
+		Ω(os.Unsetenv("TESTORG_TESTSERVICE_DATABASE__PORT")).Should(BeNil())
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	It("records the origin of every value", func() {
+		cfg.Layered = true
+		cfg.EnvOverrides = true
+		cfg.DefaultData = []byte("untouched: yes\n")
+		writeTestFile(cfg.systemURI().Path, "debug: true\ndatabase:\n  host: db0\n  port: 5432\n")
+		writeTestFile(cfg.userURI().Path, "# user\ndatabase:\n  host: db1\n")
+		Ω(os.Setenv("TESTORG_TESTSERVICE_DATABASE__PORT", "5433")).Should(BeNil())
+
+		dst := new(envConfig)
+		p, err := cfg.LoadProvenance(context.Background(), dst)
+		Ω(err).Should(BeNil())
+		Ω(dst.Database).Should(Equal(envDatabase{Host: "db1", Port: 5433}))
+		Ω(p).Should(Equal(Provenance{
+			"untouched":     {URI: defaultDataOrigin, Position: Position{Line: 1, Column: 1}},
+			"debug":         {URI: cfg.systemURI().Path, Position: Position{Line: 1, Column: 1}},
+			"database.host": {URI: cfg.userURI().Path},
+			"database.port": {URI: "env:TESTORG_TESTSERVICE_DATABASE__PORT"},
+		}))
+		Ω(p.Lookup("database.host").String()).Should(Equal(cfg.userURI().Path))
+		Ω(p.Lookup("debug").String()).Should(Equal(cfg.systemURI().Path + ":1:1"))
+
+		var buf bytes.Buffer
+		Ω(p.Fprint(&buf, dst)).Should(BeNil())
+		Ω(buf.String()).Should(Equal(`api-key = ""
+database.host = "db1"    # ` + cfg.userURI().Path + `
+database.port = 5433     # env:TESTORG_TESTSERVICE_DATABASE__PORT
+database.timeout = "0s"
+debug = true             # ` + cfg.systemURI().Path + `:1:1
+token = ""
+untouched = "true"       # default:1:1
+`))
+	})
+
+	It("records keys of a single config file", func() {
+		writeTestFile(cfg.userURI().Path, "# user\ndebug: true\ndatabase:\n  host: db1\n")
+
+		p, err := cfg.LoadProvenance(context.Background(), new(envConfig))
+		Ω(err).Should(BeNil())
+		Ω(p).Should(Equal(Provenance{
+			"debug":         {URI: cfg.userURI().String(), Position: Position{Line: 2, Column: 1}},
+			"database.host": {URI: cfg.userURI().String()},
+		}))
+	})
+
+	It("doesn't record anything for plain loads", func() {
+		Ω(cfg.provenance).Should(BeNil())
+	})
+})
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Level is a level of the config hierarchy that Save writes to.
+type Level int
+
+// Levels of the config hierarchy.
+const (
+	// UserLevel is the user config, i.e. ~/.config/podhub/canary/config.yaml
+	UserLevel Level = iota
+
+	// SystemLevel is the system config with the highest precedence, i.e.
+	// /etc/podhub/canary/config.yaml
+	SystemLevel
+)
+
+// Permissions of the files and directories created by Save and WriteFile.
+// User configs often hold credentials, so Save creates them readable by
+// their owner only.
+const (
+	SaveFileMode os.FileMode = 0644
+	SaveDirMode  os.FileMode = 0755
+
+	SaveUserFileMode os.FileMode = 0600
+	SaveUserDirMode  os.FileMode = 0700
+)
+
+var (
+	// ErrNilMarshaller is returned by Save when the format has no marshaller
+	ErrNilMarshaller = errors.New("config: nil marshaller")
+
+	// ErrUnknownLevel is returned by Save for a level it cannot write to,
+	// including levels without a search path
+	ErrUnknownLevel = errors.New("config: unknown level")
+)
+
+// Save marshals src and writes it to the config file at level, creating its
+// directory if needed. The file is replaced atomically by renaming a
+// temporary file over it, keeping the mode of an existing file, and
+// concurrent saves are serialized with an advisory lock where the platform
+// supports it. New user configs and their directories are only accessible
+// by their owner.
+//
+// The config file at level is the first search path, see SearchPaths, that
+// refers to {system_config} for SystemLevel or doesn't for UserLevel. An
+// existing system config in any system directory is replaced, new ones are
+// created in SystemBase rather than a directory of $XDG_CONFIG_DIRS. With
+// Formats, an existing config file at level decides the format, otherwise
+// src is saved in FileFormat or the first of Formats. With ConfigTags,
+// struct fields are named by their config tags.
+func (c Config) Save(src interface{}, level Level) (err error) {
+	format := c.defaultFormat()
+	if format == nil {
+		err = ErrNilFileFormat
+		return
+	}
+
+	path, format, err := c.savePath(level, format)
+	if err != nil {
+		return
+	}
+	if format.Marshaller == nil {
+		err = ErrNilMarshaller
+		return
+	}
+
+	if c.ConfigTags {
+		src, err = encodeTree(src, ConfigTag)
+		if err != nil {
+			return
+		}
+	}
+	data, err := format.Marshaller(src)
+	if err != nil {
+		return
+	}
+
+	if level == UserLevel {
+		err = writeFile(path, data, SaveUserFileMode, SaveUserDirMode)
+		return
+	}
+	err = writeFile(path, data, SaveFileMode, SaveDirMode)
+	return
+}
+
+// WriteFile replaces the file at path with data like Save does: atomically,
+// under an advisory lock, keeping the mode of an existing file and creating
+// missing directories. New files and directories get SaveFileMode and
+// SaveDirMode.
+func WriteFile(path string, data []byte) (err error) {
+	err = writeFile(path, data, SaveFileMode, SaveDirMode)
+	return
+}
+
+// writeFile is WriteFile with the modes of new files and directories.
+func writeFile(path string, data []byte, fileMode, dirMode os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, dirMode)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockFile(filepath.Join(dir, "."+filepath.Base(path)+".lock"))
+	if err != nil {
+		return
+	}
+	defer func() {
+		unlockErr := unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	err = writeFileAtomic(path, data, fileMode)
+	return
+}
+
+// savePath returns the config file at level along with its format.
+func (c Config) savePath(level Level, fallback *FileFormat) (path string, format *FileFormat, err error) {
+	if level != UserLevel && level != SystemLevel {
+		err = ErrUnknownLevel
+		return
+	}
+
+	// the paths of the first search path of the level, one for every system
+	// directory with {system_config}
+	pathsOf := func(cf Config) []string {
+		for _, t := range cf.searchPaths() {
+			if strings.Contains(t, placeholderSystemConfig) == (level == SystemLevel) {
+				return cf.expandSearchPath(t)
+			}
+		}
+		return nil
+	}
+
+	for _, f := range c.formats() {
+		cf := c
+		cf.FileFormat = f
+		paths := pathsOf(cf)
+		if len(paths) == 0 {
+			err = ErrUnknownLevel
+			return
+		}
+		for _, p := range paths {
+			if _, statErr := os.Stat(p); statErr == nil {
+				path, format = p, f
+				return
+			}
+		}
+	}
+
+	// new system configs go to SystemBase, which is searched last
+	cf := c
+	cf.FileFormat = fallback
+	paths := pathsOf(cf)
+	path, format = paths[len(paths)-1], fallback
+	return
+}
+
+// writeFileAtomic replaces the file at path with data by writing a temporary
+// file in the same directory and renaming it into place. New files get mode.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) (err error) {
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		return
+	}
+	err = tmp.Chmod(mode)
+	if err != nil {
+		return
+	}
+	err = tmp.Sync()
+	if err != nil {
+		return
+	}
+	err = tmp.Close()
+	if err != nil {
+		return
+	}
+	err = os.Rename(tmp.Name(), path)
+	return
+}
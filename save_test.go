@@ -0,0 +1,149 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Save", func() {
+	const savedData = "has_burrito: true\nfavorite_hero: roadhog\n"
+
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+		cfg.FileFormat = &FileFormat{
+			Extension:    yamlExtension,
+			Unmarshaller: yaml.Unmarshal,
+			Marshaller:   yaml.Marshal,
+		}
+	})
+
+	AfterEach(func() {
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	It("writes the config at the given level", func() {
+		Ω(cfg.Save(testConfigDataUnmarshalled, SystemLevel)).Should(BeNil())
+
+		info, err := os.Stat(filepath.Dir(cfg.systemURI().Path))
+		Ω(err).Should(BeNil())
+		Ω(info.Mode().Perm()).Should(Equal(SaveDirMode))
+		info, err = os.Stat(cfg.systemURI().Path)
+		Ω(err).Should(BeNil())
+		Ω(info.Mode().Perm()).Should(Equal(SaveFileMode))
+
+		td := new(configData)
+		Ω(cfg.Load(td)).Should(BeNil())
+		Ω(td).Should(Equal(testConfigDataUnmarshalled))
+
+		Ω(cfg.Save(&configData{FavoriteHero: "mercy"}, UserLevel)).Should(BeNil())
+		Ω(cfg.Path()).Should(Equal(cfg.userURI().Path))
+
+		info, err = os.Stat(filepath.Dir(cfg.userURI().Path))
+		Ω(err).Should(BeNil())
+		Ω(info.Mode().Perm()).Should(Equal(SaveUserDirMode))
+		info, err = os.Stat(cfg.userURI().Path)
+		Ω(err).Should(BeNil())
+		Ω(info.Mode().Perm()).Should(Equal(SaveUserFileMode))
+	})
+
+	It("writes to the search paths", func() {
+		cfg.SearchPaths = []string{"{home}/.{service}.{ext}", "{system_config}/{service}/{file}"}
+
+		Ω(cfg.Save(testConfigDataUnmarshalled, SystemLevel)).Should(BeNil())
+		systemPath := filepath.Join(tmpDir, "etc", service, "config.yaml")
+		Ω(cfg.Path()).Should(Equal(systemPath))
+
+		Ω(cfg.Save(&configData{FavoriteHero: "mercy"}, UserLevel)).Should(BeNil())
+		userPath := filepath.Join(tmpDir, "home", "."+service+".yaml")
+		Ω(cfg.Path()).Should(Equal(userPath))
+
+		td := new(configData)
+		Ω(cfg.Load(td)).Should(BeNil())
+		Ω(td.FavoriteHero).Should(Equal("mercy"))
+	})
+
+	It("rejects levels without a search path", func() {
+		cfg.SearchPaths = []string{"{home}/.{service}.{ext}"}
+		err := cfg.Save(testConfigDataUnmarshalled, SystemLevel)
+		Ω(err).Should(Equal(ErrUnknownLevel))
+	})
+
+	It("replaces existing files keeping their mode", func() {
+		path := cfg.userURI().Path
+		Ω(os.MkdirAll(filepath.Dir(path), 0700)).Should(BeNil())
+		Ω(ioutil.WriteFile(path, []byte("favorite_hero: mercy\n"), 0600)).Should(BeNil())
+
+		Ω(cfg.Save(testConfigDataUnmarshalled, UserLevel)).Should(BeNil())
+
+		data, err := ioutil.ReadFile(path)
+		Ω(err).Should(BeNil())
+		Ω(string(data)).Should(Equal(savedData))
+		info, err := os.Stat(path)
+		Ω(err).Should(BeNil())
+		Ω(info.Mode().Perm()).Should(Equal(os.FileMode(0600)))
+
+		entries, err := ioutil.ReadDir(filepath.Dir(path))
+		Ω(err).Should(BeNil())
+		for _, entry := range entries {
+			Ω(entry.Name()).ShouldNot(ContainSubstring(".tmp"))
+		}
+	})
+
+	It("serializes concurrent saves", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Ω(cfg.Save(testConfigDataUnmarshalled, UserLevel)).Should(BeNil())
+			}()
+		}
+		wg.Wait()
+
+		data, err := ioutil.ReadFile(cfg.userURI().Path)
+		Ω(err).Should(BeNil())
+		Ω(string(data)).Should(Equal(savedData))
+	})
+
+	It("keeps the format of an existing file", func() {
+		jsonFormat := &FileFormat{Extension: "json", Marshaller: func(v interface{}) ([]byte, error) {
+			return []byte("{}"), nil
+		}}
+		cfg.Formats = []*FileFormat{cfg.FileFormat, jsonFormat}
+		cfg.FileFormat = nil
+
+		jsonCfg := cfg
+		jsonCfg.FileFormat = jsonFormat
+		path := jsonCfg.userURI().Path
+		Ω(os.MkdirAll(filepath.Dir(path), 0755)).Should(BeNil())
+		Ω(ioutil.WriteFile(path, []byte(`{"has_burrito": false}`), 0644)).Should(BeNil())
+
+		Ω(cfg.Save(testConfigDataUnmarshalled, UserLevel)).Should(BeNil())
+		data, err := ioutil.ReadFile(path)
+		Ω(err).Should(BeNil())
+		Ω(string(data)).Should(Equal("{}"))
+	})
+
+	It("fails without a marshaller", func() {
+		cfg.FileFormat.Marshaller = nil
+		Ω(cfg.Save(testConfigDataUnmarshalled, UserLevel)).Should(Equal(ErrNilMarshaller))
+	})
+
+	It("fails for unknown levels", func() {
+		Ω(cfg.Save(testConfigDataUnmarshalled, Level(42))).Should(Equal(ErrUnknownLevel))
+	})
+})
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSearchPaths are the search path templates used when
+// Config.SearchPaths is empty: the user config, then the system configs.
+var DefaultSearchPaths = []string{
+	"{user_config}/{org}/{service}/{file}",
+	"{system_config}/{org}/{service}/{file}",
+}
+
+// placeholders that are only resolved when a template contains them, as they
+// require a system call or expand to several paths
+const (
+	placeholderSystemConfig = "{system_config}"
+	placeholderHostname     = "{hostname}"
+)
+
+// candidate is a possible config file along with the format decoding it.
+type candidate struct {
+	uri    *url.URL
+	format *FileFormat
+}
+
+// searchPaths returns SearchPaths, or DefaultSearchPaths if it is empty.
+func (c Config) searchPaths() (templates []string) {
+	templates = c.SearchPaths
+	if len(templates) == 0 {
+		templates = DefaultSearchPaths
+	}
+	return
+}
+
+// searchLevels returns the candidate config files of every search path
+// template, in order of decreasing precedence. Each level holds the
+// candidates of one template and system directory, one per format.
+func (c Config) searchLevels() (levels [][]candidate) {
+	formats := c.formats()
+	for _, t := range c.searchPaths() {
+		var tlevels [][]candidate
+		for _, f := range formats {
+			cf := c
+			cf.FileFormat = f
+			for i, path := range cf.expandSearchPath(t) {
+				if i == len(tlevels) {
+					tlevels = append(tlevels, nil)
+				}
+				tlevels[i] = appendCandidate(tlevels[i], candidate{
+					uri:    &url.URL{Path: path, Scheme: "file"},
+					format: c.formatOf(path, f),
+				})
+			}
+		}
+		levels = append(levels, tlevels...)
+	}
+	return
+}
+
+// appendCandidate appends cand to level unless its path is already in it, as
+// happens for templates that don't depend on the format.
+func appendCandidate(level []candidate, cand candidate) []candidate {
+	for _, existing := range level {
+		if existing.uri.Path == cand.uri.Path {
+			return level
+		}
+	}
+	return append(level, cand)
+}
+
+// searchURIs returns the candidate config files of every search path
+// template and format, in order of decreasing precedence.
+func (c Config) searchURIs() (uris []*url.URL) {
+	for _, level := range c.searchLevels() {
+		for _, cand := range level {
+			uris = append(uris, cand.uri)
+		}
+	}
+	return
+}
+
+// lookup returns the existing config file of every search level, in order of
+// decreasing precedence, and the candidates that were rejected along with
+// the reason. ErrAmbiguousFormat is returned when a level has config files
+// in more than one format.
+func (c Config) lookup() (found []candidate, rejected []Candidate, err error) {
+	for _, level := range c.searchLevels() {
+		var existing []string
+		for _, cand := range level {
+			if statErr := statFile(cand.uri.Path); statErr != nil {
+				c.trace("config: candidate rejected", "uri", cand.uri.Path, "reason", statErr)
+				rejected = append(rejected, Candidate{URI: cand.uri.Path, Err: statErr})
+				continue
+			}
+			c.trace("config: candidate exists", "uri", cand.uri.Path)
+			if len(existing) == 0 {
+				found = append(found, cand)
+			}
+			existing = append(existing, cand.uri.Path)
+		}
+		if len(existing) > 1 {
+			found = nil
+			err = fmt.Errorf("%w: %s", ErrAmbiguousFormat, strings.Join(existing, ", "))
+			return
+		}
+	}
+	return
+}
+
+// errIsDirectory is the reason given for candidates that are directories.
+var errIsDirectory = errors.New("is a directory")
+
+// statFile returns why path can't be used as a config file, if it can't.
+func statFile(path string) (err error) {
+	info, err := os.Stat(path)
+	if pathErr, ok := err.(*os.PathError); ok {
+		// the path is already part of the candidate
+		err = pathErr.Err
+		return
+	}
+	if err == nil && info.IsDir() {
+		err = errIsDirectory
+	}
+	return
+}
+
+// expandSearchPath replaces the placeholders in the search path template t,
+// returning one absolute path for every system directory if t refers to
+// them, or a single one otherwise.
+func (c Config) expandSearchPath(t string) (paths []string) {
+	var ext string
+	if c.FileFormat != nil {
+		ext = c.FileFormat.Extension
+	}
+	oldnew := []string{
+		"{org}", c.Organization,
+		"{service}", c.Service,
+		"{name}", c.baseName(),
+		"{ext}", ext,
+		"{file}", c.fileName(),
+		"{home}", c.expandPath("~/"),
+		"{user_config}", c.userBase(),
+	}
+	if strings.Contains(t, placeholderHostname) {
+		hostname, _ := os.Hostname()
+		oldnew = append(oldnew, placeholderHostname, hostname)
+	}
+
+	bases := []string{""}
+	if strings.Contains(t, placeholderSystemConfig) {
+		bases = c.systemBases()
+	}
+	for _, base := range bases {
+		r := strings.NewReplacer(append(oldnew, placeholderSystemConfig, base)...)
+		paths = append(paths, c.expandPath(r.Replace(t)))
+	}
+	return
+}
+
+// expandPath expands a leading "~/" or "$HOME" in p and makes it absolute.
+func (c Config) expandPath(p string) (exPath string) {
+	if strings.HasPrefix(p, "~/") || strings.HasPrefix(p, "$HOME") {
+		if c.pathExpander == nil {
+			p = ExpandUser(p)
+		} else {
+			p = c.pathExpander(p)
+		}
+	}
+	exPath, _ = filepath.Abs(filepath.Clean(p))
+	return
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SearchPaths", func() {
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+	})
+
+	AfterEach(func() {
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	It("defaults to the user and system configs", func() {
+		Ω(cfg.searchURIs()).Should(Equal(append([]*url.URL{cfg.userURI()}, cfg.systemURIs()...)))
+	})
+
+	It("uses FileName as the base name", func() {
+		cfg.FileName = "settings"
+		Ω(cfg.systemURI().Path).Should(Equal(filepath.Join(tmpDir, "etc", organization, service, "settings.yaml")))
+	})
+
+	It("expands placeholders", func() {
+		hostname, err := os.Hostname()
+		Ω(err).Should(BeNil())
+
+		cfg.FileName = "settings"
+		cfg.SearchPaths = []string{
+			filepath.Join(tmpDir, "opt/{org}/{service}/{name}.{ext}"),
+			"{home}/.{service}/{file}",
+			"~/{hostname}.yaml",
+			"{system_config}/{service}.yaml",
+		}
+		Ω(cfg.searchURIs()).Should(Equal([]*url.URL{
+			{Scheme: "file", Path: filepath.Join(tmpDir, "opt", organization, service, "settings.yaml")},
+			{Scheme: "file", Path: filepath.Join(tmpDir, "home", "."+service, "settings.yaml")},
+			{Scheme: "file", Path: filepath.Join(tmpDir, "home", hostname+".yaml")},
+			{Scheme: "file", Path: filepath.Join(tmpDir, "etc", service+".yaml")},
+		}))
+	})
+
+	It("resolves relative paths against the working directory", func() {
+		wd, err := os.Getwd()
+		Ω(err).Should(BeNil())
+
+		cfg.SearchPaths = []string{"./{file}"}
+		Ω(cfg.searchURIs()[0].Path).Should(Equal(filepath.Join(wd, "config.yaml")))
+	})
+
+	It("loads the first existing candidate", func() {
+		first := filepath.Join(tmpDir, "first", "config.yaml")
+		second := filepath.Join(tmpDir, "second", "config.yaml")
+		cfg.SearchPaths = []string{first, second}
+
+		_, err := cfg.URI()
+		Ω(errors.Is(err, ErrConfigFileNotFound)).Should(BeTrue())
+
+		writeTestFile(second, "has_burrito: true\nfavorite_hero: roadhog\n")
+		Ω(cfg.Path()).Should(Equal(second))
+
+		writeTestFile(first, "has_burrito: true\n")
+		Ω(cfg.Path()).Should(Equal(first))
+
+		cfg.Layered = true
+		td := new(configData)
+		Ω(cfg.Load(td)).Should(BeNil())
+		Ω(td).Should(Equal(testConfigDataUnmarshalled))
+	})
+})
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+)
+
+// ErrUnsupportedScheme is returned when a config URI has a scheme without a
+// registered Source
+var ErrUnsupportedScheme = errors.New("config: unsupported uri scheme")
+
+// Source fetches the raw contents of config URIs.
+type Source interface {
+	Fetch(ctx context.Context, uri *url.URL) ([]byte, error)
+}
+
+// TypedSource is implemented by sources that know the media type of the
+// contents they fetch, such as the Content-Type of an http response. An empty
+// contentType means it is unknown.
+type TypedSource interface {
+	Source
+	FetchTyped(ctx context.Context, uri *url.URL) (data []byte, contentType string, err error)
+}
+
+// SourceFunc adapts a function to the Source interface.
+type SourceFunc func(ctx context.Context, uri *url.URL) ([]byte, error)
+
+// Fetch implements Source.
+func (f SourceFunc) Fetch(ctx context.Context, uri *url.URL) ([]byte, error) {
+	return f(ctx, uri)
+}
+
+// configurable is implemented by sources that take settings from the Config
+// being loaded.
+type configurable interface {
+	configure(c Config) Source
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = make(map[string]Source)
+)
+
+func init() {
+	RegisterScheme("", FileSource{})
+	RegisterScheme("file", FileSource{})
+	RegisterScheme("http", &HTTPSource{})
+	RegisterScheme("https", &HTTPSource{})
+}
+
+// RegisterScheme makes source responsible for config URIs with scheme,
+// replacing any source registered before. A nil source unregisters the
+// scheme. It is safe for concurrent use.
+func RegisterScheme(scheme string, source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+
+	if source == nil {
+		delete(sources, scheme)
+		return
+	}
+	sources[scheme] = source
+}
+
+func lookupSource(scheme string) (source Source, ok bool) {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	source, ok = sources[scheme]
+	return
+}
+
+// FileSource is the Source registered for the file scheme and URIs without
+// a scheme, reading local files.
+type FileSource struct{}
+
+// Fetch implements Source.
+func (FileSource) Fetch(ctx context.Context, uri *url.URL) ([]byte, error) {
+	return readFile(ctx, uri.Path)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Source", func() {
+	AfterEach(func() {
+		RegisterScheme("memory", nil)
+	})
+
+	It("fetches registered schemes", func() {
+		var fetched *url.URL
+		RegisterScheme("memory", SourceFunc(func(ctx context.Context, uri *url.URL) ([]byte, error) {
+			fetched = uri
+			return []byte(testConfigData), nil
+		}))
+
+		data, _, err := Config{}.fetch(context.Background(), "memory://store/testorg/testservice")
+		Ω(err).Should(BeNil())
+		Ω(data).Should(Equal([]byte(testConfigData)))
+		Ω(fetched.Host).Should(Equal("store"))
+		Ω(fetched.Path).Should(Equal("/testorg/testservice"))
+	})
+
+	It("rejects unknown schemes", func() {
+		_, _, err := Config{}.fetch(context.Background(), "memory://store/config")
+		Ω(errors.Is(err, ErrUnsupportedScheme)).Should(BeTrue())
+		Ω(err.Error()).Should(ContainSubstring(`"memory"`))
+	})
+
+	It("unregisters schemes", func() {
+		RegisterScheme("memory", FileSource{})
+		RegisterScheme("memory", nil)
+		_, ok := lookupSource("memory")
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("registers the built-in sources", func() {
+		for _, scheme := range []string{"", "file", "http", "https"} {
+			_, ok := lookupSource(scheme)
+			Ω(ok).Should(BeTrue(), scheme)
+		}
+	})
+
+	It("fills http settings from the config", func() {
+		client := new(http.Client)
+		header := http.Header{"X-Test": {"1"}}
+		s := &HTTPSource{MaxBodySize: 10}
+		configured := s.configure(Config{HTTPClient: client, HTTPHeader: header, MaxBodySize: 20}).(*HTTPSource)
+		Ω(configured.Client).Should(BeIdenticalTo(client))
+		Ω(configured.Header).Should(Equal(header))
+		Ω(configured.MaxBodySize).Should(Equal(int64(10)))
+		Ω(s.Client).Should(BeNil())
+	})
+})
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"net/url"
+	"os"
+)
+
+// trace logs a step of resolving the config to Logger, if set.
+func (c Config) trace(msg string, args ...interface{}) {
+	if c.Logger == nil {
+		return
+	}
+	c.Logger.Debug(msg, args...)
+}
+
+// traceEnv logs the name and value of EnvVar(), with any password in the
+// value redacted.
+func (c Config) traceEnv() {
+	if c.Logger == nil {
+		return
+	}
+	name := c.EnvVar()
+	value, ok := os.LookupEnv(name)
+	if uri, err := url.Parse(value); err == nil {
+		value = uri.Redacted()
+	} else {
+		value = "(invalid uri)"
+	}
+	c.trace("config: environment variable", "name", name, "value", value, "set", ok)
+}
+
+// traceShadowed logs every existing config file of the search paths as
+// shadowed by the config at by.
+func (c Config) traceShadowed(by string) {
+	if c.Logger == nil {
+		return
+	}
+	for _, level := range c.searchLevels() {
+		for _, cand := range level {
+			if statFile(cand.uri.Path) == nil {
+				c.trace("config: shadowed", "uri", cand.uri.Path, "by", by)
+			}
+		}
+	}
+}
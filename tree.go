@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// unmarshalTree unmarshals data into a format-neutral tree made of
+// map[string]interface{}, []interface{} and scalar values.
+func unmarshalTree(unmarshaller Unmarshaller, data []byte) (tree map[string]interface{}, err error) {
+	if unmarshaller == nil {
+		err = ErrNilUnmarshaller
+		return
+	}
+
+	raw := make(map[string]interface{})
+	err = unmarshaller(data, &raw)
+	if err != nil {
+		return
+	}
+
+	tree, _ = normalizeTree(raw).(map[string]interface{})
+	if tree == nil {
+		tree = make(map[string]interface{})
+	}
+	return
+}
+
+// normalizeTree converts the format specific container types produced by
+// the unmarshallers into map[string]interface{} and []interface{}:
+//
+// yaml decodes nested mappings as map[interface{}]interface{}, and toml
+// decodes arrays of tables as []map[string]interface{}.
+func normalizeTree(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[k] = normalizeTree(val)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = normalizeTree(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, val := range t {
+			s[i] = normalizeTree(val)
+		}
+		return s
+	case nil:
+		return nil
+	}
+
+	// catch typed containers such as map[string]string or []string
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[fmt.Sprint(k.Interface())] = normalizeTree(rv.MapIndex(k).Interface())
+		}
+		return m
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = normalizeTree(rv.Index(i).Interface())
+		}
+		return s
+	}
+	return v
+}
+
+// mergeTree deep-merges src into dst. Nested maps present in both trees are
+// merged key by key, everything else in src replaces the value in dst.
+func mergeTree(dst, src map[string]interface{}) {
+	for k, srcVal := range src {
+		srcMap, srcIsMap := srcVal.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeTree(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			m := make(map[string]interface{}, len(srcMap))
+			mergeTree(m, srcMap)
+			srcVal = m
+		}
+		dst[k] = srcVal
+	}
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type layeredConfig struct {
+	Location string `yaml:"location"`
+	Database struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	} `yaml:"database"`
+	Tags map[string]string `yaml:"tags"`
+}
+
+var _ = Describe("Tree", func() {
+	It("normalizes yaml mappings", func() {
+		var raw map[string]interface{}
+		err := yaml.Unmarshal([]byte("a:\n  b:\n    c: 1\n"), &raw)
+		Ω(err).Should(BeNil())
+
+		tree := normalizeTree(raw)
+		Ω(tree).Should(Equal(map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": map[string]interface{}{"c": 1},
+			},
+		}))
+	})
+
+	It("keeps toml arrays of tables", func() {
+		tree, err := unmarshalTree(toml.Unmarshal, []byte("[[servers]]\nname = \"a\"\n\n[[servers]]\nname = \"b\"\n"))
+		Ω(err).Should(BeNil())
+		Ω(tree).Should(Equal(map[string]interface{}{
+			"servers": []interface{}{
+				map[string]interface{}{"name": "a"},
+				map[string]interface{}{"name": "b"},
+			},
+		}))
+	})
+
+	It("deep-merges nested maps", func() {
+		dst := map[string]interface{}{
+			"a": map[string]interface{}{"b": 1, "c": 2},
+			"l": []interface{}{1, 2},
+		}
+		mergeTree(dst, map[string]interface{}{
+			"a": map[string]interface{}{"c": 3},
+			"l": []interface{}{3},
+		})
+		Ω(dst).Should(Equal(map[string]interface{}{
+			"a": map[string]interface{}{"b": 1, "c": 3},
+			"l": []interface{}{3},
+		}))
+	})
+
+	It("rejects a nil unmarshaller", func() {
+		_, err := unmarshalTree(nil, []byte("{}"))
+		Ω(err).Should(Equal(ErrNilUnmarshaller))
+	})
+
+	Describe("Layered", func() {
+		var (
+			cfg     Config
+			tmpDir  string
+			envPath string
+		)
+
+		BeforeEach(func() {
+			tmpDir = newTestDir()
+			cfg = newTestConfig(tmpDir)
+			cfg.Layered = true
+			envPath = filepath.Join(tmpDir, "env.yaml")
+		})
+
+		AfterEach(func() {
+			Ω(os.Unsetenv(correctEnvVar)).Should(BeNil())
+			Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+		})
+
+		It("returns ErrConfigFileNotFound without any layer", func() {
+			err := cfg.Load(new(layeredConfig))
+			Ω(errors.Is(err, ErrConfigFileNotFound)).Should(BeTrue())
+		})
+
+		It("overrides system values with user and env var values", func() {
+			writeTestFile(cfg.systemURI().Path, "location: etc\ndatabase:\n  host: db0\n  port: 5432\ntags:\n  a: system\n  b: system\n")
+			writeTestFile(cfg.userURI().Path, "location: home\ndatabase:\n  host: db1\ntags:\n  b: user\n")
+			writeTestFile(envPath, "database:\n  port: 5433\n")
+			Ω(os.Setenv(correctEnvVar, envPath)).Should(BeNil())
+
+			dst := new(layeredConfig)
+			err := cfg.Load(dst)
+			Ω(err).Should(BeNil())
+			Ω(dst.Location).Should(Equal("home"))
+			Ω(dst.Database.Host).Should(Equal("db1"))
+			Ω(dst.Database.Port).Should(Equal(5433))
+			Ω(dst.Tags).Should(Equal(map[string]string{"a": "system", "b": "user"}))
+		})
+
+		It("merges layers of other formats", func() {
+			cfg.FileFormat = &FileFormat{
+				Extension:    "json",
+				Unmarshaller: json.Unmarshal,
+				Tag:          "yaml",
+			}
+			writeTestFile(cfg.systemURI().Path, `{"database": {"host": "db0", "port": 5432}}`)
+			writeTestFile(cfg.userURI().Path, `{"database": {"host": "db1"}}`)
+
+			dst := new(layeredConfig)
+			err := cfg.Load(dst)
+			Ω(err).Should(BeNil())
+			Ω(dst.Database.Host).Should(Equal("db1"))
+			Ω(dst.Database.Port).Should(Equal(5432))
+		})
+
+		It("checks to make sure dst is a pointer", func() {
+			err := cfg.Load(layeredConfig{})
+			Ω(err).Should(Equal(ErrNotAPointer))
+		})
+	})
+})
@@ -0,0 +1,353 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator is implemented by config types that check themselves once
+// loaded. Validate is called on the destination and every nested struct
+// after the rules in `validate` struct tags pass.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError describes a config value that failed validation.
+type FieldError struct {
+	// dotted key path of the value, i.e. "database.port"
+	Path string
+
+	// rule that failed, i.e. "min=1", or "Validate" for Validator errors
+	Rule string
+
+	// URI of the config the value was loaded from, empty if unknown
+	Source string
+
+	// Err describes the failure
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	msg := fmt.Sprintf("%s: %v", e.Path, e.Err)
+	if e.Path == "" {
+		msg = e.Err.Error()
+	}
+	if e.Source != "" {
+		msg = fmt.Sprintf("%s (from %s)", msg, e.Source)
+	}
+	return msg
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned by Load and Validate and lists every value that
+// failed validation.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "config: invalid config: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap exposes the individual field errors to errors.Is and errors.As.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// Validate checks dst against the rules in its `validate` struct tags and
+// calls Validate on every value implementing Validator. Rules are separated
+// by commas:
+//
+//	required     the value must not be the zero value or empty
+//	min=N        numbers must be at least N, strings, slices and maps must
+//	             have at least N elements, durations must be at least N
+//	max=N        like min, as an upper bound
+//	oneof=a b c  the value must be one of the space separated options
+//	regexp=RE    the value must match RE; it takes the rest of the tag, so it
+//	             must be the last rule
+//	url          the value must be an absolute URL
+//	hostport     the value must be a host:port pair
+//	file_exists  the value must name an existing file
+//
+// All rules except required and min/max pass for empty values. All
+// failures are returned together as a *ValidationError.
+func Validate(dst interface{}) (err error) {
+	err = validate(dst, "", nil)
+	return
+}
+
+// validate checks dst, naming values by the keys from tag, or keyTags if tag
+// is empty, and their origin in src.
+func validate(dst interface{}, tag string, src Provenance) (err error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		err = ErrNotAPointer
+		return
+	}
+
+	tags := keyTags
+	if tag != "" {
+		tags = []string{tag}
+	}
+	vd := &validator{tags: tags, src: src}
+	vd.validateValue(nil, v)
+	if len(vd.errs) > 0 {
+		err = &ValidationError{Errors: vd.errs}
+	}
+	return
+}
+
+type validator struct {
+	// struct tags consulted for key names
+	tags []string
+
+	// origins of the validated values, may be nil
+	src Provenance
+
+	errs []*FieldError
+}
+
+func (vd *validator) fail(path []string, rule string, err error) {
+	p := pathString(path)
+	vd.errs = append(vd.errs, &FieldError{
+		Path:   p,
+		Rule:   rule,
+		Source: vd.src.Lookup(p).URI,
+		Err:    err,
+	})
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// validateValue descends into structs, pointers, slices and maps.
+func (vd *validator) validateValue(path []string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		vd.validateValue(path, v.Elem())
+		return
+	case reflect.Struct:
+		vd.validateStruct(path, v)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			vd.validateValue(append(path[:len(path):len(path)], strconv.Itoa(i)), v.Index(i))
+		}
+		return
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			vd.validateValue(append(path[:len(path):len(path)], formatValue(k)), v.MapIndex(k))
+		}
+		return
+	default:
+		return
+	}
+
+	var hook Validator
+	if v.CanAddr() && v.Addr().Type().Implements(validatorType) && v.Addr().CanInterface() {
+		hook = v.Addr().Interface().(Validator)
+	} else if v.Type().Implements(validatorType) && v.CanInterface() {
+		hook = v.Interface().(Validator)
+	}
+	if hook == nil {
+		return
+	}
+	if err := hook.Validate(); err != nil {
+		vd.fail(path, "Validate", err)
+	}
+}
+
+func (vd *validator) validateStruct(path []string, v reflect.Value) {
+	for _, f := range fieldsOf(v.Type(), vd.tags...) {
+		fv := v.FieldByIndex(f.Index)
+		fieldPath := path
+		if !f.inline {
+			fieldPath = append(path[:len(path):len(path)], f.key)
+		}
+
+		for _, rule := range splitRules(f.Tag.Get("validate")) {
+			if err := checkRule(rule, fv); err != nil {
+				vd.fail(fieldPath, rule, err)
+			}
+		}
+		vd.validateValue(fieldPath, fv)
+	}
+}
+
+// splitRules splits a validate tag into rules, keeping the argument of a
+// regexp rule intact.
+func splitRules(tag string) (rules []string) {
+	for tag != "" {
+		if strings.HasPrefix(tag, "regexp=") {
+			rules = append(rules, tag)
+			return
+		}
+		i := strings.Index(tag, ",")
+		if i < 0 {
+			rules = append(rules, tag)
+			return
+		}
+		if tag[:i] != "" {
+			rules = append(rules, tag[:i])
+		}
+		tag = tag[i+1:]
+	}
+	return
+}
+
+var errRequired = errors.New("value is required")
+
+// checkRule checks v against a single rule.
+func checkRule(rule string, v reflect.Value) (err error) {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+
+	if name == "required" {
+		if isEmpty(v) {
+			err = errRequired
+		}
+		return
+	}
+	if name == "min" || name == "max" {
+		err = checkBound(name, arg, v)
+		return
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if isEmpty(v) {
+		return
+	}
+	s := formatValue(v)
+
+	switch name {
+	case "oneof":
+		options := strings.Fields(arg)
+		for _, o := range options {
+			if s == o {
+				return
+			}
+		}
+		err = fmt.Errorf("%q is not one of %s", s, strings.Join(options, ", "))
+	case "regexp":
+		var re *regexp.Regexp
+		re, err = regexp.Compile(arg)
+		if err != nil {
+			err = fmt.Errorf("invalid regexp rule: %v", err)
+			return
+		}
+		if !re.MatchString(s) {
+			err = fmt.Errorf("%q does not match %s", s, arg)
+		}
+	case "url":
+		u, parseErr := url.Parse(s)
+		if parseErr != nil {
+			err = parseErr
+			return
+		}
+		if u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == "") {
+			err = fmt.Errorf("%q is not an absolute url", s)
+		}
+	case "hostport":
+		_, port, splitErr := net.SplitHostPort(s)
+		if splitErr != nil {
+			err = splitErr
+			return
+		}
+		if _, convErr := strconv.ParseUint(port, 10, 16); convErr != nil {
+			err = fmt.Errorf("invalid port %q", port)
+		}
+	case "file_exists":
+		_, err = os.Stat(s)
+	default:
+		err = fmt.Errorf("unknown validation rule %q", name)
+	}
+	return
+}
+
+// isEmpty reports whether v is the zero value, or an empty slice or map.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// checkBound implements the min and max rules.
+func checkBound(name, arg string, v reflect.Value) (err error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	var value, bound float64
+	var isLen bool
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		value = float64(v.Len())
+		bound, err = strconv.ParseFloat(arg, 64)
+		isLen = true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(v.Int())
+		if v.Type() == durationType {
+			var d time.Duration
+			d, err = time.ParseDuration(arg)
+			bound = float64(d)
+			break
+		}
+		bound, err = strconv.ParseFloat(arg, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(v.Uint())
+		bound, err = strconv.ParseFloat(arg, 64)
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+		bound, err = strconv.ParseFloat(arg, 64)
+	default:
+		err = fmt.Errorf("%s does not apply to %s", name, v.Type())
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid %s rule: %v", name, err)
+		return
+	}
+
+	shown := formatValue(v)
+	if isLen {
+		shown = fmt.Sprintf("length %d", v.Len())
+	}
+	if name == "min" && value < bound {
+		err = fmt.Errorf("%s is less than %s", shown, arg)
+	} else if name == "max" && value > bound {
+		err = fmt.Errorf("%s is greater than %s", shown, arg)
+	}
+	return
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var errNoReplicas = errors.New("at least one replica is required in production")
+
+type validateDatabase struct {
+	Host    string        `yaml:"host" validate:"required"`
+	Port    int           `yaml:"port" validate:"min=1,max=65535"`
+	Timeout time.Duration `yaml:"timeout" validate:"min=1s"`
+}
+
+type validateConfig struct {
+	Env      string             `yaml:"env" validate:"oneof=dev prod"`
+	Name     string             `yaml:"name" validate:"required,max=8,regexp=^[a-z]+(,[a-z]+)*$"`
+	Endpoint string             `yaml:"endpoint" validate:"url"`
+	Listen   string             `yaml:"listen" validate:"hostport"`
+	CertFile string             `yaml:"cert_file" validate:"file_exists"`
+	Tags     []string           `yaml:"tags" validate:"max=2"`
+	Database validateDatabase   `yaml:"database"`
+	Replicas []validateDatabase `yaml:"replicas"`
+}
+
+func (c *validateConfig) Validate() error {
+	if c.Env == "prod" && len(c.Replicas) == 0 {
+		return errNoReplicas
+	}
+	return nil
+}
+
+func validConfig() *validateConfig {
+	return &validateConfig{
+		Env:      "dev",
+		Name:     "svc",
+		Endpoint: "https://example.com/config",
+		Listen:   ":8080",
+		Database: validateDatabase{Host: "db0", Port: 5432, Timeout: time.Second},
+	}
+}
+
+var _ = Describe("Validate", func() {
+	It("accepts valid configs", func() {
+		Ω(Validate(validConfig())).Should(BeNil())
+	})
+
+	It("checks to make sure dst is a pointer", func() {
+		Ω(Validate(*validConfig())).Should(Equal(ErrNotAPointer))
+	})
+
+	It("aggregates every failure with its key path", func() {
+		dst := validConfig()
+		dst.Env = "prod"
+		dst.Name = "Invalid"
+		dst.Endpoint = "example.com"
+		dst.Listen = "localhost:http"
+		dst.CertFile = "/nonexistent/cert.pem"
+		dst.Tags = []string{"a", "b", "c"}
+		dst.Database = validateDatabase{Port: 0, Timeout: time.Millisecond}
+
+		err := Validate(dst)
+		var verr *ValidationError
+		Ω(errors.As(err, &verr)).Should(BeTrue())
+
+		failed := map[string]string{}
+		for _, fe := range verr.Errors {
+			failed[fe.Path] = fe.Rule
+		}
+		Ω(failed).Should(Equal(map[string]string{
+			"":                 "Validate",
+			"name":             "regexp=^[a-z]+(,[a-z]+)*$",
+			"endpoint":         "url",
+			"listen":           "hostport",
+			"cert_file":        "file_exists",
+			"tags":             "max=2",
+			"database.host":    "required",
+			"database.port":    "min=1",
+			"database.timeout": "min=1s",
+		}))
+		Ω(errors.Is(err, errNoReplicas)).Should(BeTrue())
+	})
+
+	It("validates elements of slices", func() {
+		dst := validConfig()
+		dst.Replicas = []validateDatabase{{Host: "db1", Port: 70000, Timeout: time.Second}}
+
+		err := Validate(dst)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(*ValidationError).Errors[0].Path).Should(Equal("replicas.0.port"))
+	})
+
+	It("names the source of invalid values when loading", func() {
+		tmpDir := newTestDir()
+		defer os.RemoveAll(tmpDir)
+
+		cfg := newTestConfig(tmpDir)
+		cfg.Layered = true
+		cfg.Validate = true
+		cfg.Defaults = *validConfig()
+		writeTestFile(cfg.systemURI().Path, "database:\n  port: 0\n")
+		writeTestFile(cfg.userURI().Path, "env: staging\n")
+		systemPath := cfg.systemURI().Path
+		userPath := cfg.userURI().Path
+
+		err := cfg.Load(new(validateConfig))
+		Ω(err).Should(HaveOccurred())
+
+		sources := map[string]string{}
+		for _, fe := range err.(*ValidationError).Errors {
+			sources[fe.Path] = fe.Source
+		}
+		Ω(sources).Should(Equal(map[string]string{
+			"env":           userPath,
+			"database.port": systemPath,
+		}))
+		Ω(err.Error()).Should(ContainSubstring("from " + systemPath))
+	})
+
+	It("only validates when loading with Validate set", func() {
+		tmpDir := newTestDir()
+		defer os.RemoveAll(tmpDir)
+
+		cfg := newTestConfig(tmpDir)
+		writeTestFile(cfg.systemURI().Path, "email: nobody\n")
+
+		dst := new(struct {
+			Email string `yaml:"email" validate:"required,email"`
+		})
+		err := cfg.Load(dst)
+		Ω(err).Should(BeNil())
+		Ω(dst.Email).Should(Equal("nobody"))
+
+		cfg.Validate = true
+		err = cfg.Load(dst)
+		Ω(err).Should(HaveOccurred())
+	})
+})
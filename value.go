@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Load loads the config into a new T, see Config.Load.
+func Load[T any](c Config) (v T, err error) {
+	return LoadContext[T](context.Background(), c)
+}
+
+// LoadContext loads the config into a new T, see Config.LoadContext. When T
+// is a pointer type, the config is loaded into a newly allocated value.
+func LoadContext[T any](ctx context.Context, c Config) (v T, err error) {
+	dst := newDst[T]()
+	err = c.LoadContext(ctx, dst)
+	v = dstValue[T](dst)
+	return
+}
+
+// newDst returns the destination a new T is loaded into: a newly allocated
+// value when T is a pointer type, or a pointer to a zero T otherwise.
+func newDst[T any]() interface{} {
+	var v T
+	if t := reflect.TypeOf(v); t != nil && t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface()
+	}
+	return &v
+}
+
+// dstValue returns the T loaded into dst, which was returned by newDst.
+func dstValue[T any](dst interface{}) T {
+	if p, ok := dst.(*T); ok {
+		return *p
+	}
+	return dst.(T)
+}
+
+// Value holds the current snapshot of a config of type T. Get is lock-free,
+// so long-running code can call it on every use while reloads swap in new
+// snapshots. Snapshots are shared between callers and must not be modified.
+//
+// The zero Value holds the zero T until the first Store.
+type Value[T any] struct {
+	current atomic.Pointer[T]
+
+	mu          sync.Mutex
+	subscribers map[int]func(old, new T)
+	nextID      int
+}
+
+// NewValue returns a Value holding v.
+func NewValue[T any](v T) *Value[T] {
+	value := new(Value[T])
+	value.current.Store(&v)
+	return value
+}
+
+// Get returns the current snapshot.
+func (v *Value[T]) Get() (current T) {
+	if p := v.current.Load(); p != nil {
+		current = *p
+	}
+	return
+}
+
+// Store replaces the current snapshot with new and calls the subscribers
+// with the old and new snapshots.
+func (v *Value[T]) Store(new T) {
+	var old T
+	if p := v.current.Swap(&new); p != nil {
+		old = *p
+	}
+	v.notify(old, new)
+}
+
+// Subscribe registers fn to be called with the old and new snapshots on
+// every Store. The returned function removes the subscription.
+func (v *Value[T]) Subscribe(fn func(old, new T)) (unsubscribe func()) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.subscribers == nil {
+		v.subscribers = make(map[int]func(old, new T))
+	}
+	id := v.nextID
+	v.nextID++
+	v.subscribers[id] = fn
+
+	unsubscribe = func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		delete(v.subscribers, id)
+	}
+	return
+}
+
+func (v *Value[T]) notify(old, new T) {
+	v.mu.Lock()
+	subscribers := make([]func(old, new T), 0, len(v.subscribers))
+	for _, fn := range v.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	v.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(old, new)
+	}
+}
+
+// Load loads the config into a new T and stores it.
+func (v *Value[T]) Load(ctx context.Context, c Config) (err error) {
+	loaded, err := LoadContext[T](ctx, c)
+	if err != nil {
+		return
+	}
+	v.Store(loaded)
+	return
+}
+
+// Watch stores a new snapshot whenever the config changes, until ctx is
+// done, see Config.Watch. Failed reloads are passed to onError, if set, and
+// leave the current snapshot in place.
+func (v *Value[T]) Watch(ctx context.Context, c Config, onError func(error)) (err error) {
+	onChange := func(dst interface{}, loadErr error) {
+		if loadErr != nil {
+			if onError != nil {
+				onError(loadErr)
+			}
+			return
+		}
+		v.Store(dstValue[T](dst))
+	}
+	err = c.Watch(ctx, newDst[T], onChange)
+	return
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Value", func() {
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+		cfg.PollInterval = 20 * time.Millisecond
+		writeTestFile(cfg.systemURI().Path, "location: etc\n")
+	})
+
+	AfterEach(func() {
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	It("loads into a new value of the type", func() {
+		v, err := Load[watchConfig](cfg)
+		Ω(err).Should(BeNil())
+		Ω(v).Should(Equal(watchConfig{Location: "etc"}))
+
+		p, err := Load[*watchConfig](cfg)
+		Ω(err).Should(BeNil())
+		Ω(p).Should(Equal(&watchConfig{Location: "etc"}))
+
+		cfg.Organization = "missing"
+		_, err = Load[watchConfig](cfg)
+		Ω(errors.Is(err, ErrConfigFileNotFound)).Should(BeTrue())
+	})
+
+	It("notifies subscribers of new snapshots", func() {
+		v := NewValue(watchConfig{Location: "initial"})
+		Ω(v.Get().Location).Should(Equal("initial"))
+
+		var changes [][2]string
+		unsubscribe := v.Subscribe(func(old, new watchConfig) {
+			changes = append(changes, [2]string{old.Location, new.Location})
+		})
+		Ω(v.Load(context.Background(), cfg)).Should(BeNil())
+		Ω(v.Get().Location).Should(Equal("etc"))
+
+		unsubscribe()
+		v.Store(watchConfig{Location: "stored"})
+		Ω(v.Get().Location).Should(Equal("stored"))
+		Ω(changes).Should(Equal([][2]string{{"initial", "etc"}}))
+
+		var zero Value[watchConfig]
+		Ω(zero.Get()).Should(Equal(watchConfig{}))
+	})
+
+	It("swaps in reloaded configs", func() {
+		v := new(Value[watchConfig])
+		Ω(v.Load(context.Background(), cfg)).Should(BeNil())
+
+		errs := make(chan error, 10)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- v.Watch(ctx, cfg, func(err error) { errs <- err })
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		writeTestFile(cfg.userURI().Path, "location: home\n")
+		Eventually(func() string { return v.Get().Location }).Should(Equal("home"))
+
+		writeTestFile(cfg.userURI().Path, "location: [\n")
+		Eventually(errs).Should(Receive())
+		Ω(v.Get().Location).Should(Equal("home"))
+
+		cancel()
+		Eventually(done).Should(Receive(Equal(context.Canceled)))
+	})
+
+	It("swaps in reloaded configs of pointer types", func() {
+		cfg.Defaults = watchConfig{Location: "default"}
+		v := new(Value[*watchConfig])
+		Ω(v.Load(context.Background(), cfg)).Should(BeNil())
+		Ω(v.Get()).Should(Equal(&watchConfig{Location: "etc"}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- v.Watch(ctx, cfg, nil)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		writeTestFile(cfg.userURI().Path, "location: home\n")
+		Eventually(func() *watchConfig { return v.Get() }).Should(Equal(&watchConfig{Location: "home"}))
+
+		cancel()
+		Eventually(done).Should(Receive(Equal(context.Canceled)))
+	})
+})
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval is the interval at which Watch polls config files when
+// file system notifications are unavailable and PollInterval is unset.
+const DefaultPollInterval = 2 * time.Second
+
+// watchDebounce is how long Watch waits for a burst of file system events,
+// such as an editor writing a backup file and renaming it into place, to
+// settle before reloading.
+var watchDebounce = 100 * time.Millisecond
+
+var (
+	// ErrNilNewDst is returned by Watch when newDst is nil
+	ErrNilNewDst = errors.New("config: nil newDst")
+
+	// ErrNilOnChange is returned by Watch when onChange is nil
+	ErrNilOnChange = errors.New("config: nil onChange")
+)
+
+// notifier delivers a value on events() whenever something changes in one
+// of the watched directories.
+type notifier interface {
+	events() <-chan struct{}
+
+	// watch adds dirs to the watched directories
+	watch(dirs []string)
+
+	close() error
+}
+
+// newNotifier returns the platform's file system notifier, or an error if
+// there is none and Watch has to poll.
+var newNotifier = newPlatformNotifier
+
+// Watch reloads the config whenever one of the files considered by URI()
+// is created, modified or removed, until ctx is done. The whole resolution
+// hierarchy is checked again on every change, so a new user config that
+// shadows the system config is picked up. An http or https URI in EnvVar()
+// is polled with conditional requests, see Poller.
+//
+// Every reload decodes into a fresh value from newDst, which is passed to
+// onChange once Load succeeds. When Load fails, onChange receives nil and the
+// error, and the previously delivered value stays current. onChange is not
+// called for the initial state.
+//
+// File system notifications are used where available (inotify on Linux),
+// with a fallback to polling every PollInterval. Watch blocks until ctx is
+// done and returns ctx.Err().
+func (c Config) Watch(ctx context.Context, newDst func() interface{}, onChange func(interface{}, error)) (err error) {
+	if newDst == nil {
+		err = ErrNilNewDst
+		return
+	}
+	if onChange == nil {
+		err = ErrNilOnChange
+		return
+	}
+
+	var events <-chan struct{}
+	var tick <-chan time.Time
+	n, notifyErr := newNotifier()
+	if notifyErr == nil {
+		defer n.close()
+		n.watch(c.watchDirs())
+		events = n.events()
+	} else {
+		interval := c.PollInterval
+		if interval <= 0 {
+			interval = DefaultPollInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var remote <-chan struct{}
+	if uri, _ := c.envURI(); uri != nil && (uri.Scheme == "http" || uri.Scheme == "https") {
+		remote = c.watchRemote(ctx, uri.String())
+	}
+
+	last := c.snapshot()
+	var debounce <-chan time.Time
+	for {
+		var changed bool
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case <-events:
+			debounce = time.After(watchDebounce)
+			continue
+		case <-tick:
+		case <-debounce:
+			debounce = nil
+		case <-remote:
+			changed = true
+		}
+
+		if notifyErr == nil {
+			n.watch(c.watchDirs())
+		}
+		current := c.snapshot()
+		if current == last && !changed {
+			continue
+		}
+		last = current
+
+		dst := newDst()
+		loadErr := c.LoadContext(ctx, dst)
+		if loadErr != nil {
+			onChange(nil, loadErr)
+			continue
+		}
+		onChange(dst, nil)
+	}
+}
+
+// watchRemote polls the http or https config at uri and signals the returned
+// channel whenever its content changes, until ctx is done.
+func (c Config) watchRemote(ctx context.Context, uri string) <-chan struct{} {
+	p := &Poller{
+		URI:         uri,
+		Interval:    c.PollInterval,
+		Client:      c.HTTPClient,
+		Header:      c.HTTPHeader,
+		MaxBodySize: c.MaxBodySize,
+	}
+
+	ch := make(chan struct{}, 1)
+	initial := true
+	p.Subscribe(func([]byte) {
+		// the first poll reflects the state Watch started with
+		if initial {
+			initial = false
+			return
+		}
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	})
+	go p.Run(ctx)
+	return ch
+}
+
+// watchPaths returns every local file that can take part in loading the
+// config.
+func (c Config) watchPaths() (paths []string) {
+	if uri, _ := c.envURI(); uri != nil && (uri.Scheme == "file" || uri.Scheme == "") {
+		paths = append(paths, uri.Path)
+	}
+	for _, uri := range c.searchURIs() {
+		paths = append(paths, uri.Path)
+	}
+	return
+}
+
+// watchDirs returns the closest existing directory of every watch path, so
+// that creating the <org>/<service> directories is noticed as well.
+func (c Config) watchDirs() (dirs []string) {
+	seen := make(map[string]bool)
+	for _, p := range c.watchPaths() {
+		dir := filepath.Dir(p)
+		for {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return
+}
+
+// snapshot summarizes the state of every watch path, changing whenever one
+// of them is created, removed or modified.
+func (c Config) snapshot() string {
+	var states []string
+	for _, p := range c.watchPaths() {
+		info, err := os.Stat(p)
+		if err != nil {
+			states = append(states, p+":-")
+			continue
+		}
+		states = append(states, fmt.Sprintf("%s:%d:%d", p, info.Size(), info.ModTime().UnixNano()))
+	}
+	return strings.Join(states, "\n")
+}
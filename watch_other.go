@@ -0,0 +1,9 @@
+//go:build !linux
+
+package config
+
+import "errors"
+
+func newPlatformNotifier() (notifier, error) {
+	return nil, errors.New("config: file system notifications are not supported on this platform")
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type watchConfig struct {
+	Location string `yaml:"location"`
+}
+
+type watchResult struct {
+	dst *watchConfig
+	err error
+}
+
+var _ = Describe("Watch", func() {
+	var (
+		cfg     Config
+		tmpDir  string
+		cancel  context.CancelFunc
+		results chan watchResult
+		done    chan error
+	)
+
+	start := func() {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		go func() {
+			done <- cfg.Watch(ctx, func() interface{} { return new(watchConfig) }, func(v interface{}, err error) {
+				dst, _ := v.(*watchConfig)
+				results <- watchResult{dst: dst, err: err}
+			})
+		}()
+		// give the notifier time to register its watches
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+		cfg.PollInterval = 20 * time.Millisecond
+		writeTestFile(cfg.systemURI().Path, "location: etc\n")
+
+		results = make(chan watchResult, 10)
+		done = make(chan error, 1)
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(Receive(Equal(context.Canceled)))
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	behaves := func() {
+		It("reloads modified files", func() {
+			start()
+			writeTestFile(cfg.systemURI().Path, "location: modified\n")
+
+			var r watchResult
+			Eventually(results, time.Second).Should(Receive(&r))
+			Ω(r.err).Should(BeNil())
+			Ω(r.dst.Location).Should(Equal("modified"))
+		})
+
+		It("notices user configs shadowing the system config", func() {
+			start()
+			writeTestFile(cfg.userURI().Path, "location: home\n")
+
+			var r watchResult
+			Eventually(results, time.Second).Should(Receive(&r))
+			Ω(r.err).Should(BeNil())
+			Ω(r.dst.Location).Should(Equal("home"))
+		})
+
+		It("reports decode errors without a value", func() {
+			start()
+			writeTestFile(cfg.systemURI().Path, "location: [\n")
+
+			var r watchResult
+			Eventually(results, time.Second).Should(Receive(&r))
+			Ω(r.err).Should(HaveOccurred())
+			Ω(r.dst).Should(BeNil())
+		})
+	}
+
+	Describe("with notifications", behaves)
+
+	Describe("with polling", func() {
+		var orig func() (notifier, error)
+
+		BeforeEach(func() {
+			orig = newNotifier
+			newNotifier = func() (notifier, error) { return nil, errors.New("unsupported") }
+		})
+
+		AfterEach(func() {
+			newNotifier = orig
+		})
+
+		behaves()
+	})
+
+	It("polls http configs from the env var", func() {
+		var mu sync.Mutex
+		body := "location: remote\n"
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			_, err := w.Write([]byte(body))
+			Ω(err).Should(BeNil())
+		}))
+		defer ts.Close()
+		Ω(os.Setenv(correctEnvVar, ts.URL)).Should(BeNil())
+		defer os.Unsetenv(correctEnvVar)
+
+		start()
+		Consistently(results, 100*time.Millisecond).ShouldNot(Receive())
+
+		mu.Lock()
+		body = "location: changed\n"
+		mu.Unlock()
+
+		var r watchResult
+		Eventually(results, time.Second).Should(Receive(&r))
+		Ω(r.err).Should(BeNil())
+		Ω(r.dst.Location).Should(Equal("changed"))
+	})
+
+	It("requires callbacks", func() {
+		cancel = func() {}
+		done <- context.Canceled
+		Ω(cfg.Watch(context.Background(), nil, func(interface{}, error) {})).Should(Equal(ErrNilNewDst))
+		Ω(cfg.Watch(context.Background(), func() interface{} { return nil }, nil)).Should(Equal(ErrNilOnChange))
+	})
+})
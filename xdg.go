@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Environment variables of the XDG Base Directory Specification consulted
+// for the user and system config directories.
+const (
+	XDGConfigHome = "XDG_CONFIG_HOME"
+	XDGConfigDirs = "XDG_CONFIG_DIRS"
+)
+
+// userBase returns $XDG_CONFIG_HOME, or the expanded UserBase if it is unset
+// or not an absolute path.
+func (c Config) userBase() string {
+	if home := os.Getenv(XDGConfigHome); filepath.IsAbs(home) {
+		return home
+	}
+
+	if c.pathExpander == nil {
+		return ExpandUser(UserBase)
+	}
+	return c.pathExpander(UserBase)
+}
+
+// systemBases returns the absolute entries of $XDG_CONFIG_DIRS in order of
+// decreasing precedence, followed by SystemBase. Unlike the specification,
+// which defaults to /etc/xdg, SystemBase is always searched last so existing
+// system configs continue to be found when desktop sessions set the
+// variable.
+func (c Config) systemBases() (bases []string) {
+	base := SystemBase
+	if c.systemBase != "" {
+		base = c.systemBase
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv(XDGConfigDirs)) {
+		if filepath.IsAbs(dir) && filepath.Clean(dir) != filepath.Clean(base) {
+			bases = append(bases, dir)
+		}
+	}
+	bases = append(bases, base)
+	return
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("XDG", func() {
+	var (
+		cfg    Config
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir = newTestDir()
+		cfg = newTestConfig(tmpDir)
+		cfg.systemBase = ""
+	})
+
+	AfterEach(func() {
+		Ω(os.RemoveAll(tmpDir)).Should(BeNil())
+	})
+
+	It("defaults to ~/.config and /etc", func() {
+		Ω(cfg.userBase()).Should(Equal(filepath.Join(tmpDir, "home", ".config")))
+		Ω(cfg.systemBases()).Should(Equal([]string{SystemBase}))
+	})
+
+	It("uses XDG_CONFIG_HOME for the user config", func() {
+		Ω(os.Setenv(XDGConfigHome, filepath.Join(tmpDir, "xdg"))).Should(BeNil())
+		Ω(cfg.userURI().Path).Should(Equal(filepath.Join(tmpDir, "xdg", organization, service, "config.yaml")))
+	})
+
+	It("ignores relative paths", func() {
+		Ω(os.Setenv(XDGConfigHome, "relative")).Should(BeNil())
+		Ω(os.Setenv(XDGConfigDirs, "relative:also/relative")).Should(BeNil())
+		Ω(cfg.userBase()).Should(Equal(filepath.Join(tmpDir, "home", ".config")))
+		Ω(cfg.systemBases()).Should(Equal([]string{SystemBase}))
+	})
+
+	Describe("XDG_CONFIG_DIRS", func() {
+		var first, second string
+
+		BeforeEach(func() {
+			cfg.systemBase = filepath.Join(tmpDir, "etc")
+			first = filepath.Join(tmpDir, "first")
+			second = filepath.Join(tmpDir, "second")
+			Ω(os.Setenv(XDGConfigDirs, first+string(filepath.ListSeparator)+second)).Should(BeNil())
+		})
+
+		It("searches every directory in order", func() {
+			Ω(cfg.systemURI().Path).Should(Equal(filepath.Join(first, organization, service, "config.yaml")))
+
+			writeTestFile(filepath.Join(second, organization, service, "config.yaml"), "has_burrito: true\n")
+			Ω(cfg.Path()).Should(Equal(filepath.Join(second, organization, service, "config.yaml")))
+
+			writeTestFile(filepath.Join(first, organization, service, "config.yaml"), "favorite_hero: roadhog\n")
+			Ω(cfg.Path()).Should(Equal(filepath.Join(first, organization, service, "config.yaml")))
+		})
+
+		It("still searches SystemBase last", func() {
+			Ω(cfg.systemBases()).Should(Equal([]string{first, second, cfg.systemBase}))
+
+			etcPath := filepath.Join(tmpDir, "etc", organization, service, "config.yaml")
+			writeTestFile(etcPath, "has_burrito: true\n")
+			Ω(cfg.Path()).Should(Equal(etcPath))
+		})
+
+		It("saves new system configs in SystemBase", func() {
+			cfg.FileFormat.Marshaller = yaml.Marshal
+			Ω(cfg.Save(testConfigDataUnmarshalled, SystemLevel)).Should(BeNil())
+			etcPath := filepath.Join(tmpDir, "etc", organization, service, "config.yaml")
+			Ω(cfg.Path()).Should(Equal(etcPath))
+
+			secondPath := filepath.Join(second, organization, service, "config.yaml")
+			writeTestFile(secondPath, "has_burrito: false\n")
+			Ω(cfg.Save(testConfigDataUnmarshalled, SystemLevel)).Should(BeNil())
+			td := new(configData)
+			Ω(cfg.Load(td)).Should(BeNil())
+			Ω(td).Should(Equal(testConfigDataUnmarshalled))
+			Ω(cfg.Path()).Should(Equal(secondPath))
+		})
+
+		It("merges directories by precedence when layered", func() {
+			cfg.Layered = true
+			writeTestFile(filepath.Join(second, organization, service, "config.yaml"), "has_burrito: true\nfavorite_hero: mercy\n")
+			writeTestFile(filepath.Join(first, organization, service, "config.yaml"), "favorite_hero: roadhog\n")
+
+			td := new(configData)
+			err := cfg.Load(td)
+			Ω(err).Should(BeNil())
+			Ω(td).Should(Equal(testConfigDataUnmarshalled))
+		})
+	})
+})